@@ -0,0 +1,449 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"narasla_bot/backup"
+	"narasla_bot/config"
+	"narasla_bot/linkcheck"
+	"narasla_bot/sqlite"
+	"narasla_bot/storage"
+)
+
+const adminUsage = `usage: na_raslabot [command]
+
+Without a command the bot runs. Commands work on the storage from STORAGE_DRIVER
+and STORAGE_PATH and don't talk to Telegram:
+
+  migrate                     create or update the database schema
+  backup [file]               copy the sqlite database to file, or to BACKUP_DIR
+  restore <file>              replace the sqlite database with a backup, the bot must be stopped
+  export --user <id> [file]   write pages of the owner as JSON to file or stdout
+  import [--user <id>] [file] save pages from an export, read from file or stdin
+  stats                       count users and pages
+  users list                  list users and group chats with their settings
+  user disable <id>           turn autopush off for the owner, commands still work,
+                              autopush-off is the same command
+  check-links                 check links that are due now, without reports`
+
+// runAdmin runs an admin command, see adminUsage.
+func runAdmin(ctx context.Context, cfg config.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return withStorage(ctx, cfg, func(s storage.Storage) error {
+			// openStorage applies migrations of the sql backends.
+			fmt.Println("storage is up to date")
+			return nil
+		})
+	case "backup":
+		return runBackup(ctx, cfg, args[1:])
+	case "restore":
+		return runRestore(ctx, cfg, args[1:])
+	case "export":
+		return runExport(ctx, cfg, args[1:])
+	case "import":
+		return runImport(ctx, cfg, args[1:])
+	case "stats":
+		return withStorage(ctx, cfg, func(s storage.Storage) error { return printStats(ctx, s) })
+	case "users":
+		if len(args) != 2 || args[1] != "list" {
+			return errors.New("usage: users list")
+		}
+		return withStorage(ctx, cfg, func(s storage.Storage) error { return printUsers(ctx, s) })
+	case "user":
+		if len(args) != 3 || (args[1] != "disable" && args[1] != "autopush-off") {
+			return errors.New("usage: user disable <id>")
+		}
+		ownerID, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("bad owner id %q", args[2])
+		}
+		return withStorage(ctx, cfg, func(s storage.Storage) error { return autopushOff(ctx, s, ownerID) })
+	case "check-links":
+		return withStorage(ctx, cfg, func(s storage.Storage) error { return checkLinks(ctx, s, cfg.LinkCheck) })
+	case "help", "-h", "--help":
+		fmt.Println(adminUsage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], adminUsage)
+	}
+}
+
+// withStorage opens the configured storage for fn and closes it after.
+func withStorage(ctx context.Context, cfg config.Config, fn func(s storage.Storage) error) (err error) {
+	s, closeStorage, err := openStorage(ctx, cfg.StorageDriver, cfg.StoragePath)
+	if err != nil {
+		return fmt.Errorf("can't open the storage: %w", err)
+	}
+	defer func() {
+		if closeErr := closeStorage(); closeErr != nil && err == nil {
+			err = fmt.Errorf("can't close the storage: %w", closeErr)
+		}
+	}()
+
+	return fn(s)
+}
+
+func runBackup(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: backup [file]")
+	}
+
+	return withStorage(ctx, cfg, func(s storage.Storage) error {
+		db, ok := s.(backup.Database)
+		if !ok {
+			return fmt.Errorf("the %q storage has no backups", cfg.StorageDriver)
+		}
+
+		if len(args) == 1 {
+			if err := db.Backup(ctx, args[0]); err != nil {
+				return err
+			}
+			if err := db.Verify(ctx, args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("saved %s\n", args[0])
+			return nil
+		}
+
+		if cfg.Backup.Dir == "" {
+			return errors.New("set BACKUP_DIR or give a file")
+		}
+
+		path, err := backup.New(db, cfg.Backup).Make(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("saved %s\n", path)
+
+		return nil
+	})
+}
+
+// runRestore replaces the sqlite database in STORAGE_PATH with a backup.
+func runRestore(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: restore <file>")
+	}
+
+	if driver := storageDriver(cfg.StorageDriver, cfg.StoragePath); driver != "sqlite" {
+		return fmt.Errorf("restore works only with the sqlite storage, the storage is %s", driver)
+	}
+
+	if err := sqlite.Restore(ctx, args[0], cfg.StoragePath); err != nil {
+		return err
+	}
+	fmt.Printf("restored %s from %s\n", cfg.StoragePath, args[0])
+
+	return nil
+}
+
+// export is the file of export and import.
+type export struct {
+	OwnerID int64        `json:"owner_id"`
+	Pages   []exportPage `json:"pages"`
+}
+
+type exportPage struct {
+	URL          string     `json:"url"`
+	Tags         []string   `json:"tags,omitempty"`
+	Note         string     `json:"note,omitempty"`
+	Pinned       bool       `json:"pinned,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Read         bool       `json:"read,omitempty"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+}
+
+const exportPageSize = 500
+
+func runExport(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	ownerID := fs.Int64("user", 0, "owner id: a user id or a group chat id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *ownerID == 0 || fs.NArg() > 1 {
+		return errors.New("usage: export --user <id> [file]")
+	}
+
+	return withStorage(ctx, cfg, func(s storage.Storage) error {
+		exp := export{OwnerID: *ownerID, Pages: []exportPage{}}
+
+		// List doesn't carry the read time everywhere, unread pages are listed separately.
+		unread := make(map[int64]bool)
+		for offset := 0; ; offset += exportPageSize {
+			pages, err := s.List(ctx, *ownerID, "", storage.Filter{Unread: true}, exportPageSize, offset)
+			if err != nil {
+				return err
+			}
+
+			for _, p := range pages {
+				unread[p.ID] = true
+			}
+
+			if len(pages) < exportPageSize {
+				break
+			}
+		}
+
+		for offset := 0; ; offset += exportPageSize {
+			pages, err := s.List(ctx, *ownerID, "", storage.Filter{}, exportPageSize, offset)
+			if err != nil {
+				return err
+			}
+
+			for _, p := range pages {
+				exp.Pages = append(exp.Pages, exportPage{
+					URL:          p.URL,
+					Tags:         p.Tags,
+					Note:         p.Note,
+					Pinned:       p.Pinned,
+					CreatedAt:    p.CreatedAt.UTC(),
+					Read:         !unread[p.ID],
+					SnoozedUntil: timeOrNil(p.SnoozedUntil),
+				})
+			}
+
+			if len(pages) < exportPageSize {
+				break
+			}
+		}
+
+		out := io.Writer(os.Stdout)
+		if fs.NArg() == 1 {
+			file, err := os.Create(fs.Arg(0))
+			if err != nil {
+				return err
+			}
+			defer func() { _ = file.Close() }()
+			out = file
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(exp); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "exported %d pages of %d\n", len(exp.Pages), *ownerID)
+
+		return nil
+	})
+}
+
+// runImport saves pages of an export with their save dates. Pages the owner already has are skipped.
+func runImport(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	ownerID := fs.Int64("user", 0, "owner id, the one from the file by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errors.New("usage: import [--user <id>] [file]")
+	}
+
+	in := io.Reader(os.Stdin)
+	if fs.NArg() == 1 {
+		file, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		in = file
+	}
+
+	var exp export
+	if err := json.NewDecoder(in).Decode(&exp); err != nil {
+		return fmt.Errorf("can't read export: %w", err)
+	}
+	if *ownerID != 0 {
+		exp.OwnerID = *ownerID
+	}
+	if exp.OwnerID == 0 {
+		return errors.New("no owner id in the file, give --user")
+	}
+
+	return withStorage(ctx, cfg, func(s storage.Storage) error {
+		// pages are delivered to the chat they were saved in, the owner must have talked to the bot.
+		u, err := s.GetUserInfo(ctx, exp.OwnerID)
+		if err != nil {
+			return fmt.Errorf("can't import to %d: %w", exp.OwnerID, err)
+		}
+
+		chatID := u.ChatID
+		if chatID == 0 {
+			chatID = exp.OwnerID
+		}
+
+		now := time.Now()
+		var saved, skipped int
+
+		for _, ep := range exp.Pages {
+			page := &storage.Page{
+				URL:       ep.URL,
+				OwnerID:   exp.OwnerID,
+				ChatID:    chatID,
+				Tags:      ep.Tags,
+				Note:      ep.Note,
+				Pinned:    ep.Pinned,
+				CreatedAt: ep.CreatedAt,
+			}
+
+			err := s.Save(ctx, page)
+			if errors.Is(err, storage.ErrAlreadyExists) {
+				skipped++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			saved++
+
+			if ep.Read {
+				if err := s.MarkRead(ctx, page); err != nil {
+					return err
+				}
+			}
+
+			if ep.SnoozedUntil != nil && ep.SnoozedUntil.After(now) {
+				if err := s.Snooze(ctx, exp.OwnerID, page.ID, *ep.SnoozedUntil); err != nil {
+					return err
+				}
+			}
+		}
+
+		fmt.Printf("imported %d pages to %d, %d already saved\n", saved, exp.OwnerID, skipped)
+
+		return nil
+	})
+}
+
+func printStats(ctx context.Context, s storage.Storage) error {
+	users, err := s.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		people, chats, autopush           int
+		pages, unread, pinned, broken, up int
+	)
+
+	for _, u := range users {
+		if u.Kind == storage.OwnerChat {
+			chats++
+		} else {
+			people++
+		}
+		if u.Autopush {
+			autopush++
+		}
+
+		for _, c := range []struct {
+			f   storage.Filter
+			sum *int
+		}{
+			{storage.Filter{}, &pages},
+			{storage.Filter{Unread: true}, &unread},
+			{storage.Filter{Pinned: true}, &pinned},
+			{storage.Filter{Broken: true}, &broken},
+			{storage.Filter{Awake: true}, &up},
+		} {
+			n, err := s.Count(ctx, u.OwnerID, c.f)
+			if err != nil {
+				return err
+			}
+			*c.sum += n
+		}
+	}
+
+	fmt.Printf("users:   %d, group chats: %d, autopush on: %d\n", people, chats, autopush)
+	fmt.Printf("pages:   %d, unread: %d, pinned: %d, broken: %d, snoozed: %d\n", pages, unread, pinned, broken, pages-up)
+
+	return nil
+}
+
+func printUsers(ctx context.Context, s storage.Storage) error {
+	users, err := s.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tKIND\tNAME\tAUTOPUSH\tTIMEZONE\tLANG\tPAGES")
+
+	for _, u := range users {
+		n, err := s.Count(ctx, u.OwnerID, storage.Filter{})
+		if err != nil {
+			return err
+		}
+
+		autopush := "off"
+		if u.Autopush {
+			autopush = "on"
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%d\n", u.OwnerID, u.Kind, u.Username, autopush, u.Timezone, u.Lang, n)
+	}
+
+	return tw.Flush()
+}
+
+// autopushOff stops the scheduled pages of the owner, like /autopush off does.
+func autopushOff(ctx context.Context, s storage.Storage, ownerID int64) error {
+	u, err := s.GetUserInfo(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	set := u.Settings
+	set.Autopush = false
+
+	if err := s.UpdateSettings(ctx, ownerID, set); err != nil {
+		return err
+	}
+	fmt.Printf("autopush is off for %d\n", ownerID)
+
+	return nil
+}
+
+// checkLinks checks all due links batch by batch, the weekly reports stay with the running bot.
+func checkLinks(ctx context.Context, s storage.Storage, cfg linkcheck.Config) error {
+	checker := linkcheck.New(s, nil, nil, cfg)
+
+	var checked, broken int
+	for {
+		n, b, err := checker.CheckDue(ctx, time.Now())
+		checked += n
+		broken += b
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "checked %d links\n", checked)
+	}
+
+	fmt.Printf("checked %d links, %d broken\n", checked, broken)
+
+	return nil
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+
+	return &t
+}
@@ -0,0 +1,217 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	filePrefix = "storage-"
+	fileSuffix = ".db"
+	timeLayout = "20060102T150405Z"
+
+	defaultPerm = 0774
+)
+
+type Config struct {
+	Dir        string        // where backups are kept
+	Tick       time.Duration // how often to check if a backup is due
+	Every      time.Duration // a new backup is made when the last one is that old
+	KeepDaily  int           // the newest backup of each of the last days with backups
+	KeepWeekly int           // the newest backup of each of the last weeks with backups
+}
+
+func DefaultConfig(dir string) Config {
+	return Config{
+		Dir:        dir,
+		Tick:       time.Hour,
+		Every:      24 * time.Hour,
+		KeepDaily:  7,
+		KeepWeekly: 4,
+	}
+}
+
+// Backuper makes backups of the database on schedule, checks each one and drops old ones.
+type Backuper struct {
+	db  Database
+	cfg Config
+}
+
+func New(db Database, cfg Config) *Backuper {
+	return &Backuper{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Run makes a backup right away if the last one is older than Every, e.g. the bot was down,
+// and then checks again on every tick.
+func (b *Backuper) Run(ctx context.Context) error {
+	if err := b.step(ctx, time.Now()); err != nil {
+		log.Printf("backup step error: %v", err)
+	}
+
+	t := time.NewTicker(b.cfg.Tick)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			if err := b.step(ctx, time.Now()); err != nil {
+				log.Printf("backup step error: %v", err)
+			}
+		}
+	}
+}
+
+func (b *Backuper) step(ctx context.Context, now time.Time) error {
+	backups, err := List(b.cfg.Dir)
+	if err != nil {
+		return err
+	}
+
+	if len(backups) > 0 && now.Sub(backups[0].At) < b.cfg.Every {
+		return nil
+	}
+
+	path, err := b.Make(ctx, now)
+	if err != nil {
+		return err
+	}
+	log.Printf("backup: saved %s", path)
+
+	return b.prune()
+}
+
+// Make writes a backup now and checks it. The backup is written under a temporary name
+// and renamed after the check, so a broken one never counts as the last backup.
+func (b *Backuper) Make(ctx context.Context, now time.Time) (path string, err error) {
+	if err := os.MkdirAll(b.cfg.Dir, defaultPerm); err != nil {
+		return "", fmt.Errorf("backup: can't create dir: %w", err)
+	}
+
+	path = filepath.Join(b.cfg.Dir, fileName(now))
+	tmp := path + ".tmp"
+
+	// a temporary file left by a crash, VACUUM INTO needs a new file.
+	_ = os.Remove(tmp)
+
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	if err := b.db.Backup(ctx, tmp); err != nil {
+		return "", fmt.Errorf("backup: %w", err)
+	}
+
+	if err := b.db.Verify(ctx, tmp); err != nil {
+		return "", fmt.Errorf("backup: check failed: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("backup: %w", err)
+	}
+
+	return path, nil
+}
+
+// prune removes backups the retention doesn't keep.
+func (b *Backuper) prune() error {
+	backups, err := List(b.cfg.Dir)
+	if err != nil {
+		return err
+	}
+
+	keep := retain(backups, b.cfg.KeepDaily, b.cfg.KeepWeekly)
+
+	for _, bk := range backups {
+		if keep[bk.Path] {
+			continue
+		}
+		if err := os.Remove(bk.Path); err != nil {
+			return fmt.Errorf("backup: can't remove old backup: %w", err)
+		}
+		log.Printf("backup: removed %s", bk.Path)
+	}
+
+	return nil
+}
+
+type Backup struct {
+	Path string
+	At   time.Time
+}
+
+// List returns backups in dir, the newest first. Files that aren't backups are skipped.
+func List(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("backup: can't list backups: %w", err)
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+
+		at, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{Path: filepath.Join(dir, name), At: at})
+	}
+
+	slices.SortFunc(backups, func(a, b Backup) int { return b.At.Compare(a.At) })
+
+	return backups, nil
+}
+
+// retain picks backups to keep from the list sorted newest first: the newest one of each
+// of the last daily days, and of each of the last weekly ISO weeks. The newest backup is always kept.
+func retain(backups []Backup, daily, weekly int) map[string]bool {
+	keep := make(map[string]bool)
+	if len(backups) == 0 {
+		return keep
+	}
+	keep[backups[0].Path] = true
+
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+
+	for _, bk := range backups {
+		day := bk.At.UTC().Format("2006-01-02")
+		if !days[day] && len(days) < daily {
+			days[day] = true
+			keep[bk.Path] = true
+		}
+
+		year, w := bk.At.UTC().ISOWeek()
+		week := fmt.Sprintf("%d-%d", year, w)
+		if !weeks[week] && len(weeks) < weekly {
+			weeks[week] = true
+			keep[bk.Path] = true
+		}
+	}
+
+	return keep
+}
+
+func fileName(at time.Time) string {
+	return filePrefix + at.UTC().Format(timeLayout) + fileSuffix
+}
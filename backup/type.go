@@ -0,0 +1,9 @@
+package backup
+
+import "context"
+
+// Database is a database that can copy itself into a file and check such a copy.
+type Database interface {
+	Backup(ctx context.Context, path string) error
+	Verify(ctx context.Context, path string) error
+}
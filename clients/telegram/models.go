@@ -11,23 +11,83 @@ type APIResponse struct {
 	Description string `json:"description"`
 }
 
+type MessageResponse struct {
+	Ok          bool            `json:"ok"`
+	Result      IncomingMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
 type Update struct {
-	ID      int              `json:"update_id"`
-	Message *IncomingMessage `json:"message"` //use pointer since Message could be nil
+	ID            int              `json:"update_id"`
+	Message       *IncomingMessage `json:"message"` //use pointer since Message could be nil
+	CallbackQuery *CallbackQuery   `json:"callback_query"`
+}
+
+// CallbackQuery comes when user presses an inline keyboard button.
+type CallbackQuery struct {
+	ID      string           `json:"id"`
+	From    From             `json:"from"`
+	Message *IncomingMessage `json:"message"` // message with the button, nil if it's too old
+	Data    string           `json:"data"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
 }
 
 type IncomingMessage struct {
-	Text string `json:"text"`
-	From From   `json:"from"`
-	Chat Chat   `json:"chat"`
+	MessageID      int64            `json:"message_id"`
+	Text           string           `json:"text"`
+	From           From             `json:"from"`
+	Chat           Chat             `json:"chat"`
+	ReplyToMessage *IncomingMessage `json:"reply_to_message"`
 }
 
 type From struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
+}
+
+type Chat struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"` // empty for private chats
+}
+
+type ChatMemberResponse struct {
+	Ok          bool       `json:"ok"`
+	Result      ChatMember `json:"result"`
+	Description string     `json:"description"`
+}
+
+type ChatMember struct {
+	Status string `json:"status"` // "creator", "administrator", "member", "restricted", "left" or "kicked"
+}
+
+type UserResponse struct {
+	Ok          bool   `json:"ok"`
+	Result      User   `json:"result"`
+	Description string `json:"description"`
+}
+
+type User struct {
 	ID       int64  `json:"id"`
+	IsBot    bool   `json:"is_bot"`
 	Username string `json:"username"`
 }
 
-type Chat struct {
-	ID   int64  `json:"id"`
+type BotCommand struct {
+	Command     string `json:"command"` // without leading "/"
+	Description string `json:"description"`
+}
+
+// BotCommandScope type is "default", "all_private_chats", "all_group_chats", ...
+type BotCommandScope struct {
 	Type string `json:"type"`
 }
@@ -1,15 +1,18 @@
 package telegram
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"narasla_bot/lib/e"
 	"net/http"
 	"net/url"
 	"path"
 	"strconv"
+	"strings"
 )
 
 type Client struct {
@@ -19,8 +22,14 @@ type Client struct {
 }
 
 const (
-	getUpdatesMethod  = "getUpdates"
-	sendMessageMethod = "sendMessage"
+	getUpdatesMethod          = "getUpdates"
+	sendMessageMethod         = "sendMessage"
+	editMessageTextMethod     = "editMessageText"
+	answerCallbackQueryMethod = "answerCallbackQuery"
+	getChatMemberMethod       = "getChatMember"
+	getMeMethod               = "getMe"
+	setMyCommandsMethod       = "setMyCommands"
+	sendDocumentMethod        = "sendDocument"
 )
 
 func New(host string, token string) *Client {
@@ -58,14 +67,158 @@ func (c *Client) Updates(ctx context.Context, offset int, limit int) ([]Update,
 }
 
 func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	_, err := c.SendMessageWithID(ctx, chatID, text)
+
+	return err
+}
+
+// SendMessageWithID sends the message and returns its id, so replies to it can be recognized.
+func (c *Client) SendMessageWithID(ctx context.Context, chatID int64, text string) (int64, error) {
+	q := url.Values{}
+
+	q.Add("chat_id", strconv.FormatInt(chatID, 10))
+	q.Add("text", text)
+
+	return c.sendMessage(ctx, q)
+}
+
+// SendMessageWithKeyboard sends the message with inline buttons under it and returns its id.
+func (c *Client) SendMessageWithKeyboard(ctx context.Context, chatID int64, text string, kb InlineKeyboardMarkup) (int64, error) {
+	markup, err := json.Marshal(kb)
+	if err != nil {
+		return 0, e.Wrap("failed to encode keyboard", err)
+	}
+
+	q := url.Values{}
+
+	q.Add("chat_id", strconv.FormatInt(chatID, 10))
+	q.Add("text", text)
+	q.Add("reply_markup", string(markup))
+
+	return c.sendMessage(ctx, q)
+}
+
+// EditMessageText replaces text of the bot message, nil kb removes the keyboard.
+func (c *Client) EditMessageText(ctx context.Context, chatID, messageID int64, text string, kb *InlineKeyboardMarkup) error {
 	q := url.Values{}
 
 	q.Add("chat_id", strconv.FormatInt(chatID, 10))
+	q.Add("message_id", strconv.FormatInt(messageID, 10))
 	q.Add("text", text)
 
+	if kb != nil {
+		markup, err := json.Marshal(kb)
+		if err != nil {
+			return e.Wrap("failed to encode keyboard", err)
+		}
+		q.Add("reply_markup", string(markup))
+	}
+
+	return c.doCall(ctx, editMessageTextMethod, q)
+}
+
+// AnswerCallbackQuery stops the loading indicator on the pressed button,
+// non-empty text is shown to the user as a notification.
+func (c *Client) AnswerCallbackQuery(ctx context.Context, callbackID, text string) error {
+	q := url.Values{}
+
+	q.Add("callback_query_id", callbackID)
+	if text != "" {
+		q.Add("text", text)
+	}
+
+	return c.doCall(ctx, answerCallbackQueryMethod, q)
+}
+
+func (c *Client) GetChatMember(ctx context.Context, chatID, userID int64) (*ChatMember, error) {
+	q := url.Values{}
+
+	q.Add("chat_id", strconv.FormatInt(chatID, 10))
+	q.Add("user_id", strconv.FormatInt(userID, 10))
+
+	data, err := c.doRequest(ctx, getChatMemberMethod, q)
+	if err != nil {
+		return nil, e.Wrap("getChatMember doRequest fail", err)
+	}
+
+	var res ChatMemberResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, e.Wrap("failed to decode response", err)
+	}
+
+	if !res.Ok {
+		return nil, fmt.Errorf("api error: %s", res.Description)
+	}
+
+	return &res.Result, nil
+}
+
+// GetMe returns the bot itself, it's a cheap way to check the token.
+func (c *Client) GetMe(ctx context.Context) (*User, error) {
+	data, err := c.doRequest(ctx, getMeMethod, url.Values{})
+	if err != nil {
+		return nil, e.Wrap("getMe doRequest fail", err)
+	}
+
+	var res UserResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, e.Wrap("failed to decode response", err)
+	}
+
+	if !res.Ok {
+		return nil, fmt.Errorf("api error: %s", res.Description)
+	}
+
+	return &res.Result, nil
+}
+
+// SetMyCommands publishes the command menu for the scope,
+// empty languageCode sets it for users whose language has no dedicated list.
+func (c *Client) SetMyCommands(ctx context.Context, commands []BotCommand, scope BotCommandScope, languageCode string) error {
+	cmds, err := json.Marshal(commands)
+	if err != nil {
+		return e.Wrap("failed to encode commands", err)
+	}
+
+	sc, err := json.Marshal(scope)
+	if err != nil {
+		return e.Wrap("failed to encode scope", err)
+	}
+
+	q := url.Values{}
+
+	q.Add("commands", string(cmds))
+	q.Add("scope", string(sc))
+	if languageCode != "" {
+		q.Add("language_code", languageCode)
+	}
+
+	return c.doCall(ctx, setMyCommandsMethod, q)
+}
+
+func (c *Client) sendMessage(ctx context.Context, q url.Values) (int64, error) {
 	data, err := c.doRequest(ctx, sendMessageMethod, q)
 	if err != nil {
-		return e.Wrap("sendMessage doRequest fail", err)
+		return 0, e.Wrap("sendMessage doRequest fail", err)
+	}
+
+	var res MessageResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return 0, e.Wrap("failed to decode response", err)
+	}
+
+	if !res.Ok {
+		return 0, fmt.Errorf("api error: %s", res.Description)
+	}
+
+	return res.Result.MessageID, nil
+}
+
+// doCall is for methods whose result we don't need.
+func (c *Client) doCall(ctx context.Context, method string, q url.Values) error {
+	data, err := c.doRequest(ctx, method, q)
+	if err != nil {
+		return e.Wrap(method+" doRequest fail", err)
 	}
 
 	var res APIResponse
@@ -80,9 +233,37 @@ func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) err
 	return nil
 }
 
+// SendDocument uploads data as a file with the name, caption may be empty.
+func (c *Client) SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string) error {
+	q := url.Values{}
+
+	q.Add("chat_id", strconv.FormatInt(chatID, 10))
+	if caption != "" {
+		q.Add("caption", caption)
+	}
+
+	body, err := c.doUpload(ctx, sendDocumentMethod, q, "document", fileName, data)
+	if err != nil {
+		return e.Wrap("sendDocument doUpload fail", err)
+	}
+
+	var res APIResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return e.Wrap("failed to decode response", err)
+	}
+
+	if !res.Ok {
+		return fmt.Errorf("api error: %s", res.Description)
+	}
+
+	return nil
+}
+
 // export function should be at the top of non export functions
 
-func (c *Client) doRequest(ctx context.Context, method string, query url.Values) (data []byte, err error) {
+// doRequest sends the parameters as a form in a POST body: texts of up to 4096 characters
+// and keyboards don't fit in a URL once they are escaped.
+func (c *Client) doRequest(ctx context.Context, method string, params url.Values) (data []byte, err error) {
 	defer func() { err = e.Wrap("updates doRequest fail", err) }()
 
 	u := url.URL{
@@ -91,12 +272,11 @@ func (c *Client) doRequest(ctx context.Context, method string, query url.Values)
 		Path:   path.Join(c.basePath, method),
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
-
-	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -112,3 +292,52 @@ func (c *Client) doRequest(ctx context.Context, method string, query url.Values)
 
 	return body, nil
 }
+
+// doUpload sends fields and the file as multipart/form-data, it's the only way to upload a file.
+func (c *Client) doUpload(ctx context.Context, method string, fields url.Values, fileField, fileName string, file []byte) (data []byte, err error) {
+	defer func() { err = e.Wrap("upload doRequest fail", err) }()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	for key, values := range fields {
+		for _, v := range values {
+			if err := mw.WriteField(key, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fw, err := mw.CreateFormFile(fileField, fileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(file); err != nil {
+		return nil, err
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	u := url.URL{
+		Scheme: "https",
+		Host:   c.host,
+		Path:   path.Join(c.basePath, method),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,42 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendMessageLongText(t *testing.T) {
+	text := string([]rune(strings.Repeat("Длинное сообщение на русском языке. ", 120))[:4000])
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.RawQuery != "" {
+			t.Errorf("%s with query of %d bytes, want POST with a body", r.Method, len(r.URL.RawQuery))
+		}
+		if r.URL.Path != "/bottoken/sendMessage" {
+			t.Errorf("path %s", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.PostForm.Get("chat_id") != "42" || r.PostForm.Get("text") != text {
+			t.Errorf("form chat_id=%q, text of %d bytes", r.PostForm.Get("chat_id"), len(r.PostForm.Get("text")))
+		}
+
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":7}}`))
+	}))
+	defer srv.Close()
+
+	c := New(strings.TrimPrefix(srv.URL, "https://"), "token")
+	c.client = *srv.Client()
+
+	id, err := c.SendMessageWithID(context.Background(), 42, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 7 {
+		t.Errorf("message id %d, want 7", id)
+	}
+}
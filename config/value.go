@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// value parses a setting, like flag.Value.
+type value interface {
+	Set(s string) error
+	String() string
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(strings.TrimSpace(s))
+	return nil
+}
+
+func (v *stringValue) String() string { return string(*v) }
+
+type intValue struct {
+	p        *int
+	min, max int
+}
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not a number", s)
+	}
+	if n < v.min || n > v.max {
+		return fmt.Errorf("%d is out of range %d..%d", n, v.min, v.max)
+	}
+
+	*v.p = n
+
+	return nil
+}
+
+func (v *intValue) String() string { return strconv.Itoa(*v.p) }
+
+type durationValue struct {
+	p   *time.Duration
+	min time.Duration
+}
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not a duration, use 90s, 10m, 24h", s)
+	}
+	if d < v.min {
+		return fmt.Errorf("%s is less than %s", d, v.min)
+	}
+
+	*v.p = d
+
+	return nil
+}
+
+func (v *durationValue) String() string { return v.p.String() }
+
+// switchValue is on or off, true and false work too.
+type switchValue bool
+
+func (v *switchValue) Set(s string) error {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "on", "true", "yes", "1":
+		*v = true
+	case "off", "false", "no", "0":
+		*v = false
+	default:
+		return fmt.Errorf("%q is neither on nor off", s)
+	}
+
+	return nil
+}
+
+func (v *switchValue) String() string {
+	if *v {
+		return "on"
+	}
+
+	return "off"
+}
@@ -14,19 +14,28 @@ import (
 type Consumer struct {
 	fetcher   events.Fetcher
 	processor events.Processor
-	bathSize  int
+	cfg       Config
 }
 
-const (
-	trials    = 8
-	baseDelay = 100 * time.Millisecond
-)
+type Config struct {
+	BatchSize int           // updates fetched at once, Telegram allows up to 100
+	Trials    int           // attempts to fetch updates or process an event
+	BaseDelay time.Duration // pause after the first failed attempt, doubled after each next one
+}
+
+func DefaultConfig() Config {
+	return Config{
+		BatchSize: 100,
+		Trials:    8,
+		BaseDelay: 100 * time.Millisecond,
+	}
+}
 
-func New(fetcher events.Fetcher, processor events.Processor, bathsize int) Consumer {
+func New(fetcher events.Fetcher, processor events.Processor, cfg Config) Consumer {
 	return Consumer{
 		fetcher:   fetcher,
 		processor: processor,
-		bathSize:  bathsize,
+		cfg:       cfg,
 	}
 }
 
@@ -37,7 +46,7 @@ func (c Consumer) Start(ctx context.Context) error {
 			return ctx.Err()
 		default:
 		}
-		gotEvents, err := fetchWithRetry(ctx, c.fetcher, c.bathSize, trials)
+		gotEvents, err := fetchWithRetry(ctx, c.fetcher, c.cfg.BatchSize, c.cfg.Trials, c.cfg.BaseDelay)
 		if err != nil {
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				return err
@@ -84,7 +93,7 @@ func (c *Consumer) handleEvents(ctx context.Context, batch []events.Event) error
 
 			log.Printf("got new event: %s", ev.Text)
 
-			if err := processWithRetry(ctx, c.processor, ev, trials); err != nil {
+			if err := processWithRetry(ctx, c.processor, ev, c.cfg.Trials, c.cfg.BaseDelay); err != nil {
 				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 					log.Printf("event failed: %v", err)
 				}
@@ -109,9 +118,8 @@ func sleepCtx(ctx context.Context, d time.Duration) error {
 	}
 }
 
-func fetchWithRetry(ctx context.Context, fetcher events.Fetcher, batchSize, attempts int) ([]events.Event, error) {
+func fetchWithRetry(ctx context.Context, fetcher events.Fetcher, batchSize, attempts int, delay time.Duration) ([]events.Event, error) {
 	var lastErr error
-	delay := baseDelay
 
 	for i := 0; i < attempts; i++ {
 		if ctx.Err() != nil {
@@ -136,9 +144,8 @@ func fetchWithRetry(ctx context.Context, fetcher events.Fetcher, batchSize, atte
 	return nil, lastErr
 }
 
-func processWithRetry(ctx context.Context, p events.Processor, ev events.Event, attempts int) error {
+func processWithRetry(ctx context.Context, p events.Processor, ev events.Event, attempts int, delay time.Duration) error {
 	var lastErr error
-	delay := baseDelay
 
 	for i := 0; i < attempts; i++ {
 		if ctx.Err() != nil {
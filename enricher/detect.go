@@ -0,0 +1,183 @@
+package enricher
+
+import (
+	"html"
+	"io"
+	"mime"
+	"narasla_bot/storage"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// wordsPerMinute is the reading speed used for the estimate.
+const wordsPerMinute = 200
+
+// maxBodySize limits how much of a page is read, long articles fit in it easily.
+const maxBodySize = 2 << 20
+
+// Detect tells what the page is from its url, response headers and body.
+// header and body may be nil, then only the url is used.
+// It doesn't do any requests, so it works with saved fixture pages too.
+func Detect(pageURL string, header http.Header, body io.Reader) storage.Content {
+	if kind := kindByURL(pageURL); kind != storage.KindUnknown {
+		return storage.Content{Kind: kind}
+	}
+
+	if header == nil {
+		return storage.Content{}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	switch {
+	case mediaType == "application/pdf":
+		return storage.Content{Kind: storage.KindPDF}
+	case strings.HasPrefix(mediaType, "video/"):
+		return storage.Content{Kind: storage.KindVideo}
+	case mediaType != "text/html" && mediaType != "application/xhtml+xml":
+		return storage.Content{Kind: storage.KindOther}
+	}
+
+	if body == nil {
+		return storage.Content{Kind: storage.KindArticle}
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(body, maxBodySize))
+	if err != nil {
+		return storage.Content{Kind: storage.KindArticle}
+	}
+	page := string(raw)
+
+	if isVideoPage(page) {
+		return storage.Content{Kind: storage.KindVideo}
+	}
+
+	return storage.Content{
+		Kind:        storage.KindArticle,
+		ReadMinutes: ReadingMinutes(CountWords(ArticleText(page))),
+	}
+}
+
+// kindByURL knows the sites which content is clear from the link alone.
+func kindByURL(pageURL string) storage.ContentKind {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return storage.KindUnknown
+	}
+
+	host := storage.NormalizeDomain(u.Hostname())
+	host = strings.TrimPrefix(host, "m.")
+	parts := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	switch host {
+	case "youtube.com", "music.youtube.com":
+		if len(parts) > 0 && (parts[0] == "watch" || parts[0] == "shorts" || parts[0] == "live") {
+			return storage.KindVideo
+		}
+	case "youtu.be", "vimeo.com", "player.vimeo.com", "twitch.tv":
+		if len(parts) > 0 {
+			return storage.KindVideo
+		}
+	case "github.com":
+		// github.com/<owner>/<repo>[/tree/...]: issues, pulls and the rest are regular pages.
+		if len(parts) == 2 || len(parts) > 2 && (parts[2] == "tree" || parts[2] == "blob") {
+			return storage.KindRepo
+		}
+	case "twitter.com", "x.com", "mobile.twitter.com":
+		if len(parts) >= 3 && parts[1] == "status" {
+			return storage.KindTweet
+		}
+	}
+
+	if strings.EqualFold(path.Ext(u.Path), ".pdf") {
+		return storage.KindPDF
+	}
+
+	return storage.KindUnknown
+}
+
+var ogVideo = regexp.MustCompile(`(?i)<meta[^>]+property=["']og:type["'][^>]+content=["']video`)
+
+// isVideoPage finds pages that call themselves a video, e.g. embeds on news sites.
+func isVideoPage(page string) bool {
+	return ogVideo.MatchString(page)
+}
+
+var (
+	// noise are blocks that aren't a part of the article text.
+	noise = regexp.MustCompile(`(?is)<script\b.*?</script>|<style\b.*?</style>|<noscript\b.*?</noscript>|` +
+		`<svg\b.*?</svg>|<nav\b.*?</nav>|<header\b.*?</header>|<footer\b.*?</footer>|<aside\b.*?</aside>|` +
+		`<form\b.*?</form>|<!--.*?-->`)
+	article = regexp.MustCompile(`(?is)<article\b[^>]*>(.*)</article>`)
+	mainTag = regexp.MustCompile(`(?is)<main\b[^>]*>(.*)</main>`)
+	bodyTag = regexp.MustCompile(`(?is)<body\b[^>]*>(.*)</body>`)
+	tag     = regexp.MustCompile(`(?s)<[^>]*>`)
+
+	blockEnd = regexp.MustCompile(`(?i)</(p|div|h[1-6]|li|blockquote|pre|tr|section|figcaption)>|<br\s*/?>`)
+	titleTag = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title>`)
+	h1Tag    = regexp.MustCompile(`(?is)<h1\b[^>]*>(.*?)</h1>`)
+)
+
+// ArticleText extracts readable text of the html page: the <article>, <main> or <body>
+// without scripts, menus and footers. It's rough, but enough for a reading time estimate
+// and a snapshot to read. Paragraphs are separated by blank lines.
+func ArticleText(page string) string {
+	page = noise.ReplaceAllString(page, " ")
+
+	for _, re := range []*regexp.Regexp{article, mainTag, bodyTag} {
+		if m := re.FindStringSubmatch(page); m != nil {
+			page = m[1]
+			break
+		}
+	}
+
+	// line breaks of the html source mean nothing, only the ones of block tags do.
+	page = strings.Join(strings.Fields(page), " ")
+	page = blockEnd.ReplaceAllString(page, "\n")
+	text := html.UnescapeString(tag.ReplaceAllString(page, " "))
+
+	var paragraphs []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// Title is the <title> of the html page, or its first <h1>.
+func Title(page string) string {
+	for _, re := range []*regexp.Regexp{titleTag, h1Tag} {
+		if m := re.FindStringSubmatch(page); m != nil {
+			title := html.UnescapeString(tag.ReplaceAllString(m[1], " "))
+			if title = strings.Join(strings.Fields(title), " "); title != "" {
+				return title
+			}
+		}
+	}
+
+	return ""
+}
+
+// CountWords counts words with at least one letter or digit, so dashes and bullets don't count.
+func CountWords(text string) int {
+	n := 0
+
+	for _, w := range strings.Fields(text) {
+		if strings.IndexFunc(w, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) != -1 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// ReadingMinutes rounds up, any text takes at least a minute. 0 words is 0, the time is unknown.
+func ReadingMinutes(words int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
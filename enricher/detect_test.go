@@ -0,0 +1,139 @@
+package enricher
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"narasla_bot/storage"
+)
+
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func htmlHeader() http.Header {
+	return http.Header{"Content-Type": []string{"text/html; charset=utf-8"}}
+}
+
+func TestDetectByURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want storage.ContentKind
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", storage.KindVideo},
+		{"https://m.youtube.com/shorts/abc", storage.KindVideo},
+		{"https://youtu.be/dQw4w9WgXcQ", storage.KindVideo},
+		{"https://vimeo.com/76979871", storage.KindVideo},
+		{"https://github.com/golang/go", storage.KindRepo},
+		{"https://github.com/golang/go/tree/master/src", storage.KindRepo},
+		{"https://github.com/golang/go/issues/1", storage.KindUnknown},
+		{"https://x.com/golang/status/123", storage.KindTweet},
+		{"https://example.com/paper.PDF", storage.KindPDF},
+		{"https://example.com/post", storage.KindUnknown},
+		{"https://www.youtube.com/", storage.KindUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := Detect(tt.url, nil, nil); got.Kind != tt.want {
+			t.Errorf("Detect(%s) = %q, want %q", tt.url, got.Kind, tt.want)
+		}
+	}
+}
+
+func TestDetectByHeader(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        storage.ContentKind
+	}{
+		{"application/pdf", storage.KindPDF},
+		{"video/mp4", storage.KindVideo},
+		{"image/png", storage.KindOther},
+		{"application/json", storage.KindOther},
+		{"text/html", storage.KindArticle},
+		{"application/xhtml+xml; charset=utf-8", storage.KindArticle},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{"Content-Type": []string{tt.contentType}}
+		if got := Detect("https://example.com/file", header, nil); got.Kind != tt.want {
+			t.Errorf("Detect with %s = %q, want %q", tt.contentType, got.Kind, tt.want)
+		}
+	}
+}
+
+func TestDetectFixtures(t *testing.T) {
+	tests := []struct {
+		file    string
+		want    storage.ContentKind
+		minutes int
+	}{
+		{"article.html", storage.KindArticle, 2},
+		{"main.html", storage.KindArticle, 1},
+		{"video.html", storage.KindVideo, 0},
+	}
+
+	for _, tt := range tests {
+		got := Detect("https://example.com/"+tt.file, htmlHeader(), strings.NewReader(fixture(t, tt.file)))
+		if got.Kind != tt.want || got.ReadMinutes != tt.minutes {
+			t.Errorf("%s: %q for %d min, want %q for %d min", tt.file, got.Kind, got.ReadMinutes, tt.want, tt.minutes)
+		}
+	}
+}
+
+func TestArticleText(t *testing.T) {
+	text := ArticleText(fixture(t, "article.html"))
+
+	if !strings.HasPrefix(text, "Why sourdough needs time\n\nSourdough is the oldest way to raise bread.") {
+		t.Errorf("text starts with %q", text[:min(len(text), 80)])
+	}
+	if got := strings.Count(text, "\n\n"); got != 6 {
+		t.Errorf("%d paragraph breaks, want 6 for a heading, 5 paragraphs and a caption", got)
+	}
+	for _, noise := range []string{"Recipes", "analytics", "font-family", "Subscribe", "All rights reserved", "ad slot", "The Bread Blog"} {
+		if strings.Contains(text, noise) {
+			t.Errorf("text has %q, it's not a part of the article", noise)
+		}
+	}
+	if got := CountWords(text); got != 292 {
+		t.Errorf("%d words, want 292", got)
+	}
+
+	want := "Release notes 2.0\n\nFaster start—twice as fast on large projects.\n\nNew --dry-run flag.\n\nUpgrade with one command.\n\nNo config changes needed."
+	if got := ArticleText(fixture(t, "main.html")); got != want {
+		t.Errorf("text of <main>:\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"article.html", "Why sourdough needs time & patience | The Bread Blog"},
+		{"video.html", "Watch: the launch in 90 seconds"},
+		{"main.html", "Release notes 2.0"}, // empty <title>, the <h1> is used
+	}
+
+	for _, tt := range tests {
+		if got := Title(fixture(t, tt.file)); got != tt.want {
+			t.Errorf("Title(%s) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestReadingMinutes(t *testing.T) {
+	for words, want := range map[int]int{0: 0, 1: 1, 200: 1, 201: 2, 1000: 5} {
+		if got := ReadingMinutes(words); got != want {
+			t.Errorf("ReadingMinutes(%d) = %d, want %d", words, got, want)
+		}
+	}
+}
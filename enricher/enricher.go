@@ -0,0 +1,133 @@
+package enricher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"narasla_bot/lib/netguard"
+	"narasla_bot/storage"
+	"net/http"
+	"time"
+)
+
+const (
+	// batchSize limits pages fetched in one tick, the rest go on the next ones.
+	batchSize = 20
+
+	fetchTimeout = 15 * time.Second
+	userAgent    = "Mozilla/5.0 (compatible; narasla_bot; +https://core.telegram.org/bots)"
+)
+
+// Enricher detects content type and reading time of saved pages in the background,
+// and keeps snapshots of articles if they are on.
+type Enricher struct {
+	st        EnricherStorage
+	client    *http.Client
+	tick      time.Duration
+	snapshots bool
+}
+
+// New makes an enricher. Without a client it uses one that fetches only public addresses:
+// snapshots are shown to the user, so a link to the bot's own network must not be read.
+func New(st EnricherStorage, client *http.Client, tick time.Duration, snapshots bool) *Enricher {
+	if client == nil {
+		client = netguard.Client(fetchTimeout)
+	}
+
+	return &Enricher{
+		st:        st,
+		client:    client,
+		tick:      tick,
+		snapshots: snapshots,
+	}
+}
+
+func (en *Enricher) Run(ctx context.Context) error {
+	t := time.NewTicker(en.tick)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			if err := en.step(ctx); err != nil {
+				log.Printf("enricher step error: %v", err)
+			}
+		}
+	}
+}
+
+func (en *Enricher) step(ctx context.Context) error {
+	pages, err := en.st.ListUnenriched(ctx, batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, page := range pages {
+		c, snap := en.Enrich(ctx, page.URL)
+
+		if snap != nil {
+			snap.PageID = page.ID
+			if err := en.st.SaveSnapshot(ctx, snap); err != nil {
+				return fmt.Errorf("enricher: can't save snapshot page=%d: %w", page.ID, err)
+			}
+		}
+
+		if err := en.st.SetContent(ctx, page.ID, c); err != nil {
+			return fmt.Errorf("enricher: can't set content page=%d: %w", page.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Enrich detects the content of the page and makes a snapshot of an article if snapshots are on.
+// Links known by their url aren't fetched; if the page can't be fetched, the content stays
+// unknown and it isn't retried.
+func (en *Enricher) Enrich(ctx context.Context, pageURL string) (storage.Content, *storage.Snapshot) {
+	if kind := kindByURL(pageURL); kind != storage.KindUnknown {
+		return storage.Content{Kind: kind}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return storage.Content{}, nil
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+
+	resp, err := en.client.Do(req)
+	if err != nil {
+		return storage.Content{}, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return storage.Content{}, nil
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return storage.Content{}, nil
+	}
+
+	c := Detect(pageURL, resp.Header, bytes.NewReader(raw))
+	if !en.snapshots || c.Kind != storage.KindArticle {
+		return c, nil
+	}
+
+	page := string(raw)
+
+	text := ArticleText(page)
+	if text == "" {
+		return c, nil
+	}
+
+	return c, &storage.Snapshot{Title: Title(page), Text: text}
+}
@@ -0,0 +1,53 @@
+package enricher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"narasla_bot/storage"
+)
+
+func articleServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	page, err := os.ReadFile("testdata/article.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestEnrichSnapshot(t *testing.T) {
+	srv := articleServer(t)
+	en := New(nil, srv.Client(), time.Minute, true)
+
+	c, snap := en.Enrich(context.Background(), srv.URL+"/sourdough")
+	if c.Kind != storage.KindArticle || c.ReadMinutes != 2 {
+		t.Errorf("content is %q for %d min, want an article for 2 min", c.Kind, c.ReadMinutes)
+	}
+	if snap == nil || snap.Title != "Why sourdough needs time & patience | The Bread Blog" || snap.Text == "" {
+		t.Fatalf("snapshot is %+v", snap)
+	}
+}
+
+// TestEnrichLocal checks that the default client doesn't read the bot's own network.
+func TestEnrichLocal(t *testing.T) {
+	srv := articleServer(t)
+	en := New(nil, nil, time.Minute, true)
+
+	c, snap := en.Enrich(context.Background(), srv.URL+"/sourdough")
+	if c.Kind != storage.KindUnknown || snap != nil {
+		t.Errorf("a local page was fetched: %q, snapshot %v", c.Kind, snap != nil)
+	}
+}
@@ -0,0 +1,12 @@
+package enricher
+
+import (
+	"context"
+	"narasla_bot/storage"
+)
+
+type EnricherStorage interface {
+	ListUnenriched(ctx context.Context, limit int) ([]storage.Page, error)
+	SetContent(ctx context.Context, pageID int64, c storage.Content) error
+	SaveSnapshot(ctx context.Context, s *storage.Snapshot) error
+}
@@ -7,6 +7,9 @@ import (
 	"log"
 	"narasla_bot/clients/telegram"
 	"narasla_bot/lib/e"
+	"narasla_bot/lib/i18n"
+	"narasla_bot/lib/pagetext"
+	"narasla_bot/selector"
 	"narasla_bot/storage"
 	"net/url"
 	"strconv"
@@ -14,8 +17,6 @@ import (
 	"time"
 )
 
-const limit = 20
-
 const (
 	SaveCmd     = "/save"
 	RndCmd      = "/rnd"
@@ -24,10 +25,33 @@ const (
 	DeleteCmd   = "/del"
 	ListCmd     = "/list"
 	AutopushCmd = "/autopush"
+	UndoCmd     = "/undo"
+	GroupCmd    = "/group"
+	LangCmd     = "/lang"
+	CancelCmd   = "/cancel"
+	SettingsCmd = "/settings"
+	SnoozeCmd   = "/snooze"
+	PinCmd      = "/pin"
+	UnpinCmd    = "/unpin"
+	ReadCmd     = "/read"
+
+	// SavePinnedCmd is /save that pins the page. It's not a valid name
+	// for the Telegram menu, so it works only when typed.
+	SavePinnedCmd = "/save!"
+)
+
+const (
+	undoCallback       = "undo"
+	bulkDeleteCallback = "bulkdel"
+	flowCallback       = "flow"
+	settingsCallback   = "set"
+	snoozeCallback     = "snooze" // "Not now" button, the scheduler puts it under delivered pages
+	pinCallback        = "pin"
+	brokenCallback     = "broken" // buttons of the weekly report of the link checker
 )
 
 func (p *Processor) doCmd(ctx context.Context, text string, m Meta) error {
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.CmdTimeout)
 	defer cancel()
 
 	if err := p.updateUserInfo(ctx, m); err != nil {
@@ -39,8 +63,27 @@ func (p *Processor) doCmd(ctx context.Context, text string, m Meta) error {
 		return nil
 	}
 
+	m, err := p.resolveOwner(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	if m, err = p.resolveLang(ctx, m); err != nil {
+		return err
+	}
+
+	if handled, err := p.doFlow(ctx, text, m); handled || err != nil {
+		return err
+	}
+
+	if m.ReplyToID != 0 && !strings.HasPrefix(text, "/") {
+		if handled, err := p.attachNote(ctx, m, text); handled || err != nil {
+			return err
+		}
+	}
+
 	if isAddCmd(text) {
-		return p.savePage(ctx, m.Chat.ID, m.UserID, text, m.Username)
+		return p.savePage(ctx, m, text, false)
 	}
 
 	parts := strings.Fields(text)
@@ -71,7 +114,8 @@ func (p *Processor) doCmd(ctx context.Context, text string, m Meta) error {
 
 func (p *Processor) updateUserInfo(ctx context.Context, m Meta) error {
 	if m.Chat.Type == "private" {
-		if err := p.storage.UpdateUserInfo(ctx, m.UserID, m.Chat.ID, m.Username); err != nil {
+		lang, _ := i18n.Parse(m.LangCode)
+		if err := p.storage.UpdateUserInfo(ctx, m.UserID, m.Chat.ID, m.Username, string(lang)); err != nil {
 			return err
 		}
 	}
@@ -79,6 +123,45 @@ func (p *Processor) updateUserInfo(ctx context.Context, m Meta) error {
 	return nil
 }
 
+// resolveLang sets m.Lang: the one chosen with /lang, or the Telegram client language.
+func (p *Processor) resolveLang(ctx context.Context, m Meta) (Meta, error) {
+	m.Lang, _ = i18n.Parse(m.LangCode)
+
+	user, err := p.storage.GetUserInfo(ctx, m.UserID)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return m, nil
+	}
+	if err != nil {
+		return m, e.Wrap("Commands: can't resolve language", err)
+	}
+
+	if lang, ok := i18n.Parse(user.Lang); ok {
+		m.Lang = lang
+	}
+
+	return m, nil
+}
+
+// owner returns the list owner, with default settings if the owner is unknown yet.
+func (p *Processor) owner(ctx context.Context, ownerID int64) (storage.User, error) {
+	user, err := p.storage.GetUserInfo(ctx, ownerID)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return storage.User{OwnerID: ownerID}, nil
+	}
+	if err != nil {
+		return storage.User{}, e.Wrap("Commands: can't get owner", err)
+	}
+
+	return *user, nil
+}
+
+// ownerSettings returns settings of the list owner, defaults if the owner is unknown yet.
+func (p *Processor) ownerSettings(ctx context.Context, ownerID int64) (storage.Settings, error) {
+	user, err := p.owner(ctx, ownerID)
+
+	return user.Settings, err
+}
+
 func (p *Processor) resolveCmd(cmdRaw, chatType string) (string, bool) {
 	isPrivate := chatType == "private"
 	mentionBot := strings.Contains(cmdRaw, "@")
@@ -109,19 +192,30 @@ func (p *Processor) normalizeCmd(cmdRaw string) (string, bool) {
 	return cmd, true
 }
 
-func (p *Processor) savePage(ctx context.Context, chatID, userID int64, pageURL, username string) (err error) {
+// savePage saves the link, pinned pages are delivered before the others.
+func (p *Processor) savePage(ctx context.Context, m Meta, text string, pinned bool) (err error) {
 	defer func() { err = e.Wrap("Commands: can't do savePage", err) }()
 
-	sendMsg := newMessageSender(ctx, chatID, p.tg)
+	chatID, ownerID := m.Chat.ID, m.OwnerID
+
+	sendMsg := newMessageSender(ctx, m, p.tg)
+
+	pageURL, tags, note, ok := parseSaveArgs(text)
+	if !ok {
+		return p.sendUsage(ctx, m, SaveCmd)
+	}
 
 	page := &storage.Page{
 		URL:      pageURL,
-		OwnerID:  userID,
+		OwnerID:  ownerID,
 		ChatID:   chatID,
-		UserName: username,
+		UserName: m.Username,
+		Tags:     tags,
+		Note:     note,
+		Pinned:   pinned,
 	}
 
-	isExists, err := p.storage.IsExists(ctx, userID, pageURL)
+	isExists, err := p.storage.IsExists(ctx, ownerID, pageURL)
 	if err != nil {
 		return err
 	}
@@ -134,19 +228,53 @@ func (p *Processor) savePage(ctx context.Context, chatID, userID int64, pageURL,
 		return err
 	}
 
-	if err := sendMsg(msgSaved); err != nil {
+	msgID, err := p.tg.SendMessageWithKeyboard(ctx, chatID, m.t(msgSaved), pinKeyboard(m.Lang, page.ID, pinned))
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return p.storage.SaveMessageRef(ctx, chatID, msgID, page.ID)
 }
 
-func (p *Processor) sendRandom(ctx context.Context, chatID, userID int64) (err error) {
+// attachNote sets the text as a note of the page the replied "Saved!" message is about.
+// handled is false for replies to any other message, they are handled as usual messages,
+// so a link sent as a reply is saved.
+func (p *Processor) attachNote(ctx context.Context, m Meta, note string) (handled bool, err error) {
+	defer func() { err = e.Wrap("Commands: can't attach note", err) }()
+
+	pageID, err := p.storage.PageIDByMessage(ctx, m.Chat.ID, m.ReplyToID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = p.storage.SetNote(ctx, m.OwnerID, pageID, note)
+	if errors.Is(err, storage.ErrNotFound) {
+		// the page belongs to someone else in the group.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgNoteSaved))
+}
+
+func (p *Processor) sendRandom(ctx context.Context, m Meta, filter storage.Filter) (err error) {
 	defer func() { err = e.Wrap("Commands: can't do sendRandom", err) }()
 
-	sendMsg := newMessageSender(ctx, chatID, p.tg)
+	chatID, ownerID := m.Chat.ID, m.OwnerID
 
-	randPage, err := p.storage.PickRandom(ctx, userID)
+	sendMsg := newMessageSender(ctx, m, p.tg)
+
+	owner, err := p.owner(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	randPage, err := selector.ClaimRandom(ctx, p.storage, owner, filter)
 	if err != nil {
 		if errors.Is(err, storage.ErrNoSavedPages) {
 			return sendMsg(msgNoSavedPages)
@@ -159,48 +287,64 @@ func (p *Processor) sendRandom(ctx context.Context, chatID, userID int64) (err e
 		return sendMsg(msgNoSavedPages)
 	}
 
-	if err := p.tg.SendMessage(ctx, chatID, randPage.URL); err != nil {
-		return err
+	if err := p.tg.SendMessage(ctx, chatID, pagetext.Text(m.Lang, randPage)); err != nil {
+		return errors.Join(err, p.storage.Release(ctx, randPage))
 	}
 
-	return p.storage.Remove(ctx, randPage)
+	return p.storage.Ack(ctx, randPage, owner.Archive)
 }
 
-func (p *Processor) sendHello(ctx context.Context, chatID, userID int64) error {
-	return p.tg.SendMessage(ctx, chatID, msgHello)
+func (p *Processor) sendHello(ctx context.Context, m Meta) error {
+	return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgHello)+"\n\n"+p.helpText(m))
 }
 
-func (p *Processor) sendHelp(ctx context.Context, chatID, userID int64) error {
-	return p.tg.SendMessage(ctx, chatID, msgHelp)
+// sendHelp sends the list of commands, or help of one command if it's given.
+func (p *Processor) sendHelp(ctx context.Context, m Meta, name string) error {
+	if name == "" {
+		return p.tg.SendMessage(ctx, m.Chat.ID, p.helpText(m))
+	}
+
+	c, ok := p.lookupCmd(name)
+	if !ok {
+		return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgUnknownCommand))
+	}
+
+	return p.tg.SendMessage(ctx, m.Chat.ID, p.commandHelp(m, c))
 }
 
-func (p *Processor) removePage(ctx context.Context, chatID, userID int64, username, arg string) (err error) {
+func (p *Processor) removePage(ctx context.Context, m Meta, arg string) (err error) {
 	defer func() { err = e.Wrap("Commands: can't delete page", err) }()
 
-	sendMsg := newMessageSender(ctx, chatID, p.tg)
-	arg = strings.TrimSpace(arg)
+	ownerID, username := m.OwnerID, m.Username
+
+	sendMsg := newMessageSender(ctx, m, p.tg)
+	sendMsgN := newPluralMessageSender(ctx, m, p.tg)
 
 	if arg == "" {
-		return p.sendList(ctx, chatID, userID, username)
+		return p.sendList(ctx, m, storage.Filter{})
 	}
 
 	if isURL(arg) {
-		if err := p.storage.RemoveByURL(ctx, userID, arg); err != nil {
+		pageID, err := p.storage.RemoveByURL(ctx, ownerID, arg)
+		if err != nil {
 			if errors.Is(err, storage.ErrNotFound) {
 				return sendMsg(msgNoSavedPages)
 			}
 
 			return err
 		}
-		return sendMsg(msgDeleted)
+		return p.sendDeleted(ctx, m, pageID)
 	}
 
 	num, err := strconv.Atoi(arg)
-	if err != nil || num <= 0 {
-		return sendMsg(msgIncorrectDeleteArg)
+	if err != nil {
+		return p.confirmBulkDelete(ctx, m, arg)
+	}
+	if num <= 0 {
+		return p.sendUsage(ctx, m, DeleteCmd)
 	}
 
-	list, err := p.storage.List(ctx, userID, username, limit, 0)
+	list, err := p.storage.List(ctx, ownerID, username, storage.Filter{}, p.cfg.ListLimit, 0)
 	if err != nil {
 		return err
 	}
@@ -210,8 +354,7 @@ func (p *Processor) removePage(ctx context.Context, chatID, userID int64, userna
 	}
 
 	if num > len(list) {
-		return sendMsg(
-			fmt.Sprintf("You have only %d items in the list. Send /del to see them.", len(list)))
+		return sendMsgN(msgOnlyItems, len(list))
 	}
 
 	page := list[num-1]
@@ -219,50 +362,197 @@ func (p *Processor) removePage(ctx context.Context, chatID, userID int64, userna
 		return err
 	}
 
-	return sendMsg(msgDeleted)
+	return p.sendDeleted(ctx, m, page.ID)
 }
 
-func (p *Processor) sendList(ctx context.Context, chatID, userID int64, username string) (err error) {
+// confirmBulkDelete counts pages matched by bulk /del arguments
+// and asks the user to confirm before anything is removed.
+func (p *Processor) confirmBulkDelete(ctx context.Context, m Meta, arg string) error {
+	chatID, ownerID := m.Chat.ID, m.OwnerID
+
+	sendMsg := newMessageSender(ctx, m, p.tg)
+	sendMsgN := newPluralMessageSender(ctx, m, p.tg)
+	now := time.Now()
+
+	filter, nums, err := parseDeleteArgs(arg, now)
+	if err != nil {
+		return p.sendUsage(ctx, m, DeleteCmd)
+	}
+
+	if len(nums) > 0 {
+		maxNum := nums[len(nums)-1]
+
+		// Numbers are the ones /list shows, so ranges stop where single numbers do.
+		list, err := p.storage.List(ctx, ownerID, m.Username, storage.Filter{}, p.cfg.ListLimit, 0)
+		if err != nil {
+			return err
+		}
+
+		if maxNum > len(list) {
+			return sendMsgN(msgOnlyItems, len(list))
+		}
+
+		filter.IDs = make([]int64, 0, len(nums))
+		for _, n := range nums {
+			filter.IDs = append(filter.IDs, list[n-1].ID)
+		}
+	}
+
+	count, err := p.storage.Count(ctx, ownerID, filter)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sendMsg(msgNothingMatches)
+	}
+
+	token, err := p.pending.add(ownerID, m.UserID, filter, now)
+	if err != nil {
+		return err
+	}
+
+	text := m.n(msgConfirmBulkDelete, count, count)
+	_, err = p.tg.SendMessageWithKeyboard(ctx, chatID, text, confirmKeyboard(m.Lang, bulkDeleteCallback, token))
+
+	return err
+}
+
+// removeBroken removes all pages the link checker has found broken and returns text for the user.
+// Removed pages go to the undo journal.
+func (p *Processor) removeBroken(ctx context.Context, m Meta) (text string, err error) {
+	defer func() { err = e.Wrap("Commands: can't remove broken pages", err) }()
+
+	removed, err := p.storage.RemoveFiltered(ctx, m.OwnerID, storage.Filter{Broken: true})
+	if err != nil {
+		return "", err
+	}
+
+	return m.n(msgBulkDeleted, removed, removed), nil
+}
+
+// bulkDelete runs the confirmed bulk delete and returns text for the user.
+// Only the user who asked for it can confirm.
+func (p *Processor) bulkDelete(ctx context.Context, m Meta, token string) (text string, err error) {
+	defer func() { err = e.Wrap("Commands: can't do bulk delete", err) }()
+
+	pending, ok := p.pending.take(token, m.UserID, time.Now())
+	if !ok {
+		return m.t(msgConfirmationExpired), nil
+	}
+
+	removed, err := p.storage.RemoveFiltered(ctx, pending.ownerID, pending.filter)
+	if err != nil {
+		return "", err
+	}
+
+	return m.n(msgBulkDeleted, removed, removed), nil
+}
+
+// sendDeleted confirms removal with an "Undo" button under the message.
+func (p *Processor) sendDeleted(ctx context.Context, m Meta, pageID int64) error {
+	_, err := p.tg.SendMessageWithKeyboard(ctx, m.Chat.ID, m.t(msgDeleted), undoKeyboard(m.Lang, pageID))
+
+	return err
+}
+
+// undo restores the removed page, or all pages of the last removal if pageID is 0,
+// and returns text for the user.
+func (p *Processor) undo(ctx context.Context, m Meta, pageID int64) (text string, err error) {
+	defer func() { err = e.Wrap("Commands: can't undo", err) }()
+
+	pages, err := p.storage.Undo(ctx, m.OwnerID, pageID)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return m.t(msgNothingToUndo), nil
+	case errors.Is(err, storage.ErrAlreadyExists):
+		return m.t(msgAlreadyExists), nil
+	case err != nil:
+		return "", err
+	}
+
+	if len(pages) == 1 {
+		return m.t(msgRestored, pages[0].URL), nil
+	}
+
+	return m.n(msgRestoredPages, len(pages), len(pages)), nil
+}
+
+func (p *Processor) sendList(ctx context.Context, m Meta, filter storage.Filter) (err error) {
 	defer func() { err = e.Wrap("Command: can't send list", err) }()
 
-	sendMsg := newMessageSender(ctx, chatID, p.tg)
+	chatID, ownerID, username := m.Chat.ID, m.OwnerID, m.Username
 
-	list, err := p.storage.List(ctx, userID, username, limit, 0)
+	sendMsg := newMessageSender(ctx, m, p.tg)
+
+	list, err := p.storage.List(ctx, ownerID, username, filter, p.cfg.ListLimit, 0)
 	if err != nil {
 		return err
 	}
 	if len(list) == 0 {
+		if !filter.IsEmpty() {
+			return sendMsg(msgNothingMatches)
+		}
 		return sendMsg(msgNoSavedPages)
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("@%s 's saved pages:\n\n", username))
+	if isGroupList(m) {
+		sb.WriteString(m.t(msgGroupListHeader, m.Chat.Title) + "\n\n")
+	} else {
+		sb.WriteString(m.t(msgListHeader, username) + "\n\n")
+	}
+
+	set, err := p.ownerSettings(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
 
 	for i, p := range list {
-		sb.WriteString(fmt.Sprintf("%d. — %s\n", i+1, p.URL))
+		star := ""
+		if p.Pinned {
+			star = "★ "
+		}
+		if p.Broken {
+			star += "⚠ "
+		}
+
+		sb.WriteString(fmt.Sprintf("%d. — %s%s%s%s\n", i+1, star, p.URL, formatContent(m, p.Content), formatTags(p.Tags)))
+		if p.Note != "" {
+			sb.WriteString("    " + pagetext.Note(m.Lang, p.Note) + "\n")
+		}
+		if p.SnoozedUntil.After(now) {
+			sb.WriteString("    " + m.t(msgSnoozedLabel, p.SnoozedUntil.In(set.Location()).Format(timeLayout)) + "\n")
+		}
 	}
 
-	sb.WriteString("\nDelete: /del <number> or /del <url>")
+	// numbers of a filtered list don't match the ones /del <number> uses.
+	if filter.IsEmpty() {
+		sb.WriteString("\n" + m.t(msgListFooter))
+	} else {
+		sb.WriteString("\n" + m.t(msgFilteredListFooter))
+	}
 
-	return sendMsg(sb.String())
+	return p.tg.SendMessage(ctx, chatID, sb.String())
 }
 
-func (p *Processor) autopush(ctx context.Context, chatID, userID int64, arg string) (err error) {
+// autopush handles "on", "off", "status" or "" which toggles it.
+func (p *Processor) autopush(ctx context.Context, m Meta, arg string) (err error) {
 	defer func() { err = e.Wrap("Command: can't change autopush status", err) }()
 
-	sendMsg := newMessageSender(ctx, chatID, p.tg)
-	var desired bool
+	ownerID := m.OwnerID
 
-	arg = strings.ToLower(strings.TrimSpace(arg))
+	sendMsg := newMessageSender(ctx, m, p.tg)
+	var desired bool
 
-	user, err := p.storage.GetUserInfo(ctx, userID)
+	user, err := p.storage.GetUserInfo(ctx, ownerID)
 	if errors.Is(err, storage.ErrUserNotFound) {
 		return sendMsg(msgUnknownUser)
 	}
 	if err != nil {
 		return err
 	}
-	desired = user.Enabled
+	desired = user.Autopush
 
 	switch arg {
 	case "status":
@@ -274,13 +564,14 @@ func (p *Processor) autopush(ctx context.Context, chatID, userID int64, arg stri
 		desired = true
 	case "off":
 		desired = false
-	case "":
-		desired = !user.Enabled
 	default:
-		return sendMsg(msgIncorrectAutopush)
+		desired = !user.Autopush
 	}
 
-	if err := p.storage.SwitchEnable(ctx, userID, desired); err != nil {
+	set := user.Settings
+	set.Autopush = desired
+
+	if err := p.storage.UpdateSettings(ctx, ownerID, set); err != nil {
 		return err
 	}
 
@@ -290,15 +581,111 @@ func (p *Processor) autopush(ctx context.Context, chatID, userID int64, arg stri
 	return sendMsg(msgAutopushTurnedOff)
 }
 
+// changeLang sets the language of the user, without argument it shows the current one.
+func (p *Processor) changeLang(ctx context.Context, m Meta, arg string) (err error) {
+	defer func() { err = e.Wrap("Command: can't change language", err) }()
+
+	sendMsg := newMessageSender(ctx, m, p.tg)
+
+	lang, ok := i18n.Parse(arg)
+	if !ok {
+		return sendMsg(msgLangStatus)
+	}
+
+	user, err := p.storage.GetUserInfo(ctx, m.UserID)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return sendMsg(msgUnknownUser)
+	}
+	if err != nil {
+		return err
+	}
+
+	set := user.Settings
+	set.Lang = string(lang)
+
+	if err := p.storage.UpdateSettings(ctx, m.UserID, set); err != nil {
+		return err
+	}
+
+	m.Lang = lang
+
+	return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgLangChanged))
+}
+
 // using wrapper reduces redundant usage of chatID in savePage func, and makes code more readable.
-func newMessageSender(ctx context.Context, chatID int64, tgClient *telegram.Client) func(string) error {
-	return func(msg string) error {
-		return tgClient.SendMessage(ctx, chatID, msg)
+// It sends the message in the language of the user.
+func newMessageSender(ctx context.Context, m Meta, tgClient *telegram.Client) func(i18n.Key, ...any) error {
+	return func(key i18n.Key, args ...any) error {
+		return tgClient.SendMessage(ctx, m.Chat.ID, m.t(key, args...))
+	}
+}
+
+// newPluralMessageSender works like newMessageSender, n selects the plural form and is the first argument.
+func newPluralMessageSender(ctx context.Context, m Meta, tgClient *telegram.Client) func(i18n.Key, int) error {
+	return func(key i18n.Key, n int) error {
+		return tgClient.SendMessage(ctx, m.Chat.ID, m.n(key, n, n))
 	}
 }
 
 func isAddCmd(text string) bool {
-	return isURL(text)
+	_, _, _, ok := parseSaveArgs(text)
+	return ok
+}
+
+// noteSeparators split the link part from the note: "<url> — note text".
+var noteSeparators = []string{" — ", " -- ", " – "}
+
+// parseSaveArgs splits "<url> #tag #tag — note" into url, tags and note.
+func parseSaveArgs(text string) (pageURL string, tags []string, note string, ok bool) {
+	for _, sep := range noteSeparators {
+		if before, after, found := strings.Cut(text, sep); found {
+			text, note = before, strings.TrimSpace(after)
+			break
+		}
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !isURL(fields[0]) {
+		return "", nil, "", false
+	}
+
+	for _, f := range fields[1:] {
+		if !isTag(f) {
+			return "", nil, "", false
+		}
+		tags = append(tags, strings.ToLower(f[1:]))
+	}
+
+	return fields[0], tags, note, true
+}
+
+var kindLabels = map[storage.ContentKind]i18n.Key{
+	storage.KindVideo: msgKindVideo,
+	storage.KindPDF:   msgKindPDF,
+	storage.KindRepo:  msgKindRepo,
+	storage.KindTweet: msgKindTweet,
+}
+
+// formatContent is " · 7 min" for articles and " · video" and so on for the rest,
+// empty while the content is unknown.
+func formatContent(m Meta, c storage.Content) string {
+	if c.ReadMinutes > 0 {
+		return " · " + m.t(msgReadMinutes, c.ReadMinutes)
+	}
+
+	if key, ok := kindLabels[c.Kind]; ok {
+		return " · " + m.t(key)
+	}
+
+	return ""
+}
+
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	return " #" + strings.Join(tags, " #")
 }
 
 func isURL(text string) bool {
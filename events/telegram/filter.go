@@ -0,0 +1,203 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"narasla_bot/storage"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	ErrBadFilter = errors.New("bad filter expression")
+	ErrBadAge    = errors.New("bad duration")
+)
+
+// Reading time of "short" and "long" filters, in minutes.
+const (
+	shortReadMinutes = 5
+	longReadMinutes  = 20
+)
+
+// contentKinds are filter terms for the content type of a page.
+var contentKinds = map[string]storage.ContentKind{
+	"article": storage.KindArticle,
+	"video":   storage.KindVideo,
+	"pdf":     storage.KindPDF,
+	"repo":    storage.KindRepo,
+	"tweet":   storage.KindTweet,
+}
+
+// parseFilter parses /list and /rnd arguments, terms can be combined freely:
+//
+//	oldest | newest | unread | pinned | broken | short | long | article | video | pdf | repo | tweet |
+//	domain:<host> | since:<age> | #<tag>
+func parseFilter(arg string, now time.Time) (storage.Filter, error) {
+	var f storage.Filter
+
+	for _, term := range strings.Fields(strings.ToLower(arg)) {
+		key, value, hasValue := strings.Cut(term, ":")
+
+		switch {
+		case term == "oldest":
+			f.Order = storage.OrderOldest
+		case term == "newest":
+			f.Order = storage.OrderNewest
+		case term == "unread":
+			f.Unread = true
+		case term == "pinned":
+			f.Pinned = true
+		case term == "broken":
+			f.Broken = true
+		case term == "short":
+			f.MaxMinutes = shortReadMinutes
+		case term == "long":
+			f.MinMinutes = longReadMinutes
+		case contentKinds[term] != storage.KindUnknown:
+			f.Kind = contentKinds[term]
+		case hasValue && key == "domain" && value != "":
+			f.Domain = storage.NormalizeDomain(value)
+		case hasValue && key == "since":
+			age, err := parseAge(value)
+			if err != nil {
+				return storage.Filter{}, fmt.Errorf("%w: %s", ErrBadFilter, term)
+			}
+			f.Since = now.Add(-age)
+		case isTag(term):
+			f.Tags = append(f.Tags, term[1:])
+		default:
+			return storage.Filter{}, fmt.Errorf("%w: %s", ErrBadFilter, term)
+		}
+	}
+
+	return f, nil
+}
+
+// parseAge parses durations like 12h, 7d, 2w, 3m (30 days) or 1y.
+func parseAge(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, ErrBadAge
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, ErrBadAge
+	}
+
+	day := 24 * time.Hour
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = day
+	case 'w':
+		unit = 7 * day
+	case 'm':
+		unit = 30 * day
+	case 'y':
+		unit = 365 * day
+	default:
+		return 0, ErrBadAge
+	}
+
+	return time.Duration(n) * unit, nil
+}
+
+func isTag(s string) bool {
+	if len(s) < 2 || s[0] != '#' {
+		return false
+	}
+
+	for _, r := range s[1:] {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// maxListNumber limits ranges like 1-100000 in /del.
+const maxListNumber = 1000
+
+// parseDeleteArgs parses bulk /del arguments, terms can be combined:
+//
+//	all | domain:<host> | older:<age> | 1-5,8,12
+//
+// Numbers are positions in /list, the caller resolves them to page ids.
+func parseDeleteArgs(arg string, now time.Time) (f storage.Filter, nums []int, err error) {
+	terms := strings.Fields(strings.ToLower(arg))
+	if len(terms) == 0 {
+		return storage.Filter{}, nil, ErrBadFilter
+	}
+
+	for _, term := range terms {
+		key, value, hasValue := strings.Cut(term, ":")
+
+		switch {
+		case term == "all":
+			// empty filter matches every page.
+		case hasValue && key == "domain" && value != "":
+			f.Domain = storage.NormalizeDomain(value)
+		case hasValue && key == "older":
+			age, err := parseAge(value)
+			if err != nil {
+				return storage.Filter{}, nil, fmt.Errorf("%w: %s", ErrBadFilter, term)
+			}
+			f.Before = now.Add(-age)
+		default:
+			n, err := parseRanges(term)
+			if err != nil {
+				return storage.Filter{}, nil, fmt.Errorf("%w: %s", ErrBadFilter, term)
+			}
+			nums = append(nums, n...)
+		}
+	}
+
+	sort.Ints(nums)
+
+	return f, slices.Compact(nums), nil
+}
+
+// parseRanges parses "1-5,8,12" into sorted unique numbers.
+func parseRanges(s string) ([]int, error) {
+	seen := make(map[int]bool)
+
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		if !isRange {
+			to = from
+		}
+
+		lo, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, err
+		}
+
+		if lo <= 0 || hi < lo || hi > maxListNumber {
+			return nil, ErrBadFilter
+		}
+
+		for n := lo; n <= hi; n++ {
+			seen[n] = true
+		}
+	}
+
+	nums := make([]int, 0, len(seen))
+	for n := range seen {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	return nums, nil
+}
@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"narasla_bot/lib/e"
+	"narasla_bot/storage"
+	"strings"
+	"time"
+)
+
+// flowTTL is how long the bot waits for the answer before it forgets the question.
+const flowTTL = 10 * time.Minute
+
+const (
+	saveFlow = "save"
+
+	pinnedStep = "pinned" // step of saveFlow started by /save!
+)
+
+// flowHandler gets the answer: text of the next message of the user in the chat
+// or payload of a "flow:<payload>" callback.
+type flowHandler func(ctx context.Context, c *storage.Conversation, input string, m Meta) error
+
+func (p *Processor) initFlows() {
+	p.flows = map[string]flowHandler{
+		saveFlow:     p.fSave,
+		timezoneFlow: p.fTimezone,
+	}
+}
+
+// await makes the next message of the user in the chat go to the flow instead of commands.
+// step and data are kept for the flow handler.
+func (p *Processor) await(ctx context.Context, m Meta, flow, step, data string) error {
+	c := &storage.Conversation{
+		ChatID:    m.Chat.ID,
+		UserID:    m.UserID,
+		Flow:      flow,
+		Step:      step,
+		Data:      data,
+		ExpiresAt: time.Now().Add(flowTTL),
+	}
+
+	if err := p.storage.SetConversation(ctx, c); err != nil {
+		return e.Wrap("Commands: can't start conversation", err)
+	}
+
+	return nil
+}
+
+func (p *Processor) endFlow(ctx context.Context, m Meta) error {
+	if err := p.storage.DeleteConversation(ctx, m.Chat.ID, m.UserID); err != nil {
+		return e.Wrap("Commands: can't end conversation", err)
+	}
+
+	return nil
+}
+
+// conversation returns the conversation the bot is in with the user, or nil.
+func (p *Processor) conversation(ctx context.Context, m Meta) (*storage.Conversation, error) {
+	c, err := p.storage.GetConversation(ctx, m.Chat.ID, m.UserID)
+	if errors.Is(err, storage.ErrNoConversation) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, e.Wrap("Commands: can't get conversation", err)
+	}
+
+	return c, nil
+}
+
+// doFlow is checked before commands: a plain message answers the question,
+// a command leaves the conversation and runs as usual.
+func (p *Processor) doFlow(ctx context.Context, text string, m Meta) (handled bool, err error) {
+	c, err := p.conversation(ctx, m)
+	if err != nil || c == nil {
+		return false, err
+	}
+
+	if strings.HasPrefix(text, "/") {
+		return false, p.endFlow(ctx, m)
+	}
+
+	return true, p.continueFlow(ctx, c, text, m)
+}
+
+// continueFlow ends the conversation and passes the answer to its flow,
+// the flow calls await again if it needs one more answer.
+func (p *Processor) continueFlow(ctx context.Context, c *storage.Conversation, input string, m Meta) error {
+	if err := p.endFlow(ctx, m); err != nil {
+		return err
+	}
+
+	h, ok := p.flows[c.Flow]
+	if !ok {
+		// the flow was removed while the user was thinking.
+		return nil
+	}
+
+	return h(ctx, c, input, m)
+}
+
+// askLink starts the save flow: /save without a link asks for it.
+func (p *Processor) askLink(ctx context.Context, m Meta, pinned bool) error {
+	step := ""
+	if pinned {
+		step = pinnedStep
+	}
+
+	if err := p.await(ctx, m, saveFlow, step, ""); err != nil {
+		return err
+	}
+
+	return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgAskLink))
+}
+
+func (p *Processor) fSave(ctx context.Context, c *storage.Conversation, input string, m Meta) error {
+	pinned := c.Step == pinnedStep
+
+	if !isAddCmd(input) {
+		return p.askLink(ctx, m, pinned)
+	}
+
+	return p.savePage(ctx, m, input, pinned)
+}
@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"narasla_bot/lib/e"
+	"narasla_bot/storage"
+)
+
+// resolveOwner sets m.OwnerID: the group chat when its shared list is on,
+// the sender otherwise.
+func (p *Processor) resolveOwner(ctx context.Context, m Meta) (Meta, error) {
+	m.OwnerID = m.UserID
+
+	if isPrivate(m) {
+		return m, nil
+	}
+
+	chat, err := p.storage.GetUserInfo(ctx, m.Chat.ID)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return m, nil
+	}
+	if err != nil {
+		return m, e.Wrap("Commands: can't resolve owner", err)
+	}
+
+	if chat.Kind == storage.OwnerChat && chat.Shared {
+		m.OwnerID = m.Chat.ID
+	}
+
+	return m, nil
+}
+
+// groupList turns the shared list of the group on and off, only admins can change it.
+// Private chats never get here, /group is registered for groups only.
+func (p *Processor) groupList(ctx context.Context, m Meta, arg string) (err error) {
+	defer func() { err = e.Wrap("Commands: can't change group list mode", err) }()
+
+	sendMsg := newMessageSender(ctx, m, p.tg)
+
+	var desired bool
+
+	switch arg {
+	case "on":
+		desired = true
+	case "off":
+		desired = false
+	default:
+		if isGroupList(m) {
+			return sendMsg(msgGroupListOn)
+		}
+		return sendMsg(msgGroupListOff)
+	}
+
+	ok, err := p.requireAdmin(ctx, m)
+	if err != nil || !ok {
+		return err
+	}
+
+	if err := p.storage.UpdateChatInfo(ctx, m.Chat.ID, m.Chat.Title); err != nil {
+		return err
+	}
+
+	if err := p.storage.SwitchShared(ctx, m.Chat.ID, desired); err != nil {
+		return err
+	}
+
+	if desired {
+		return sendMsg(msgGroupListOn)
+	}
+	return sendMsg(msgGroupListOff)
+}
+
+// requireAdmin checks the sender with getChatMember and tells them if they are not an admin.
+func (p *Processor) requireAdmin(ctx context.Context, m Meta) (bool, error) {
+	member, err := p.tg.GetChatMember(ctx, m.Chat.ID, m.UserID)
+	if err != nil {
+		return false, e.Wrap("Commands: can't check admin", err)
+	}
+
+	if member.Status == "creator" || member.Status == "administrator" {
+		return true, nil
+	}
+
+	return false, p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgAdminsOnly))
+}
+
+func isPrivate(m Meta) bool {
+	return m.Chat.Type == "private"
+}
+
+// isGroupList reports whether the message works with the group's shared list.
+func isGroupList(m Meta) bool {
+	return !isPrivate(m) && m.OwnerID == m.Chat.ID
+}
@@ -2,60 +2,367 @@ package telegram
 
 import (
 	"context"
+	"errors"
+	"narasla_bot/lib/e"
+	"narasla_bot/lib/i18n"
+	"narasla_bot/storage"
+	"strconv"
 	"strings"
 )
 
 func (p *Processor) initHandlers() {
-	p.handlers = map[string]handler{
-		SaveCmd:     p.hSave,
-		RndCmd:      p.hRand,
-		HelpCmd:     p.hHelp,
-		StartCmd:    p.hStart,
-		DeleteCmd:   p.hDel,
-		ListCmd:     p.hList,
-		AutopushCmd: p.hAutopush,
+	onOff := argSpec{kind: argChoice, choices: []string{"on", "off", "status"}}
+
+	langs := make([]string, 0, len(i18n.Langs))
+	for _, l := range i18n.Langs {
+		langs = append(langs, string(l))
+	}
+
+	p.commands = []command{
+		{
+			name: SaveCmd, handler: p.hSave,
+			description: msgCmdSave, usage: msgUsageSave, details: msgDetailsSave,
+			scopes: scopeAll, args: argSpec{kind: argText},
+		},
+		{
+			name: SavePinnedCmd, handler: p.hSavePinned,
+			description: msgCmdSavePinned, usage: msgUsageSavePinned,
+			scopes: scopeAll, args: argSpec{kind: argText}, noMenu: true,
+		},
+		{
+			name: RndCmd, aliases: []string{"/random"}, handler: p.hRand,
+			description: msgCmdRnd, usage: msgUsageRnd, details: msgDetailsFilter,
+			scopes: scopeAll, args: argSpec{kind: argFilter},
+		},
+		{
+			name: ListCmd, aliases: []string{"/ls"}, handler: p.hList,
+			description: msgCmdList, descArgs: []any{p.cfg.ListLimit}, usage: msgUsageList, details: msgDetailsFilter,
+			scopes: scopeAll, args: argSpec{kind: argFilter},
+		},
+		{
+			name: DeleteCmd, aliases: []string{"/delete", "/rm"}, handler: p.hDel,
+			description: msgCmdDel, usage: msgUsageDel, details: msgDetailsDel,
+			scopes: scopeAll, args: argSpec{kind: argText},
+		},
+		{
+			name: PinCmd, handler: p.hPin,
+			description: msgCmdPin, usage: msgUsagePin,
+			scopes: scopeAll, args: argSpec{kind: argText, required: true},
+		},
+		{
+			name: UnpinCmd, handler: p.hUnpin,
+			description: msgCmdUnpin, usage: msgUsageUnpin,
+			scopes: scopeAll, args: argSpec{kind: argText, required: true},
+		},
+		{
+			name: ReadCmd, handler: p.hRead,
+			description: msgCmdRead, usage: msgUsageRead, details: msgDetailsRead,
+			scopes: scopeAll, args: argSpec{kind: argText, required: true},
+		},
+		{
+			name: SnoozeCmd, handler: p.hSnooze,
+			description: msgCmdSnooze, usage: msgUsageSnooze, details: msgDetailsSnooze,
+			scopes: scopeAll, args: argSpec{kind: argText, required: true},
+		},
+		{
+			name: UndoCmd, handler: p.hUndo,
+			description: msgCmdUndo, usage: msgUsageUndo,
+			scopes: scopeAll, args: argSpec{kind: argNone},
+		},
+		{
+			name: AutopushCmd, handler: p.hAutopush,
+			description: msgCmdAutopush, usage: msgUsageAutopush, details: msgDetailsAutopush,
+			scopes: scopeAll, args: onOff,
+		},
+		{
+			name: GroupCmd, handler: p.hGroup,
+			description: msgCmdGroup, usage: msgUsageGroup, details: msgDetailsGroup,
+			scopes: scopeGroup, args: onOff,
+		},
+		{
+			name: SettingsCmd, handler: p.hSettings,
+			description: msgCmdSettings, usage: msgUsageSettings,
+			scopes: scopeAll, args: argSpec{kind: argNone},
+		},
+		{
+			name: LangCmd, aliases: []string{"/language"}, handler: p.hLang,
+			description: msgCmdLang, usage: msgUsageLang,
+			scopes: scopeAll, args: argSpec{kind: argChoice, choices: langs},
+		},
+		{
+			name: CancelCmd, handler: p.hCancel,
+			description: msgCmdCancel, usage: msgUsageCancel,
+			scopes: scopeAll, args: argSpec{kind: argNone},
+		},
+		{
+			name: HelpCmd, handler: p.hHelp,
+			description: msgCmdHelp, usage: msgUsageHelp,
+			scopes: scopeAll, args: argSpec{kind: argText},
+		},
+		{
+			name: StartCmd, handler: p.hStart,
+			description: msgCmdStart, usage: msgUsageStart,
+			scopes: scopePrivate, args: argSpec{kind: argText}, // deep link payload is ignored
+		},
+	}
+
+	p.handlers = make(map[string]*command, len(p.commands))
+	for i := range p.commands {
+		c := &p.commands[i]
+
+		p.handlers[c.name] = c
+		for _, alias := range c.aliases {
+			p.handlers[alias] = c
+		}
 	}
 }
 
-func (p *Processor) middleHandler(ctx context.Context, cmd, arg string, m Meta) error {
-	h, ok := p.handlers[cmd]
+func (p *Processor) initCallbacks() {
+	p.callbacks = map[string]callbackHandler{
+		undoCallback:       p.cbUndo,
+		bulkDeleteCallback: p.cbBulkDelete,
+		flowCallback:       p.cbFlow,
+		settingsCallback:   p.cbSettings,
+		snoozeCallback:     p.cbSnooze,
+		pinCallback:        p.cbPin,
+		brokenCallback:     p.cbBroken,
+	}
+}
+
+func (p *Processor) doCallback(ctx context.Context, data string, m Meta) error {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.CmdTimeout)
+	defer cancel()
+
+	prefix, payload, _ := strings.Cut(data, ":")
+
+	h, ok := p.callbacks[prefix]
 	if !ok {
-		return p.tg.SendMessage(ctx, m.Chat.ID, msgUnknownCommand)
+		return p.tg.AnswerCallbackQuery(ctx, m.CallbackID, "")
+	}
+
+	m, err := p.resolveOwner(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	if m, err = p.resolveLang(ctx, m); err != nil {
+		return err
 	}
 
-	return h(ctx, arg, m)
+	return h(ctx, payload, m)
+}
+
+func (p *Processor) hSave(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.save(ctx, a, m, false)
+}
+
+func (p *Processor) hSavePinned(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.save(ctx, a, m, true)
 }
 
-func (p *Processor) hSave(ctx context.Context, arg string, m Meta) error {
-	arg = strings.TrimSpace(arg)
-	if arg == "" || !isAddCmd(arg) {
-		return p.tg.SendMessage(ctx, m.Chat.ID, msgIncorrectSave)
+func (p *Processor) save(ctx context.Context, a cmdArgs, m Meta, pinned bool) error {
+	if a.raw == "" {
+		return p.askLink(ctx, m, pinned)
+	}
+
+	if !isAddCmd(a.raw) {
+		return p.sendUsage(ctx, m, SaveCmd)
 	}
 
-	return p.savePage(ctx, m.Chat.ID, m.UserID, arg, m.Username)
+	return p.savePage(ctx, m, a.raw, pinned)
 }
 
-func (p *Processor) hRand(ctx context.Context, arg string, m Meta) error {
-	return p.sendRandom(ctx, m.Chat.ID, m.UserID)
+func (p *Processor) hRand(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.sendRandom(ctx, m, a.filter)
 }
 
-func (p *Processor) hHelp(ctx context.Context, arg string, m Meta) error {
-	return p.tg.SendMessage(ctx, m.Chat.ID, msgHelp)
+func (p *Processor) hHelp(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.sendHelp(ctx, m, a.raw)
 }
 
-func (p *Processor) hStart(ctx context.Context, arg string, m Meta) error {
-	return p.tg.SendMessage(ctx, m.Chat.ID, msgHello)
+func (p *Processor) hStart(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.sendHello(ctx, m)
 }
 
-func (p *Processor) hDel(ctx context.Context, arg string, m Meta) error {
-	arg = strings.TrimSpace(arg)
-	return p.removePage(ctx, m.Chat.ID, m.UserID, m.Username, arg)
+func (p *Processor) hDel(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.removePage(ctx, m, a.raw)
 }
 
-func (p *Processor) hList(ctx context.Context, arg string, m Meta) error {
-	return p.sendList(ctx, m.Chat.ID, m.UserID, m.Username)
+func (p *Processor) hList(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.sendList(ctx, m, a.filter)
 }
 
-func (p *Processor) hAutopush(ctx context.Context, arg string, m Meta) error {
-	return p.autopush(ctx, m.Chat.ID, m.UserID, arg)
+func (p *Processor) hAutopush(ctx context.Context, a cmdArgs, m Meta) error {
+	if isGroupList(m) && a.choice != "status" {
+		ok, err := p.requireAdmin(ctx, m)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	return p.autopush(ctx, m, a.choice)
+}
+
+func (p *Processor) hPin(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.pinPage(ctx, m, a.raw, true)
+}
+
+func (p *Processor) hUnpin(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.pinPage(ctx, m, a.raw, false)
+}
+
+func (p *Processor) hRead(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.readPage(ctx, m, a.raw)
+}
+
+func (p *Processor) hSnooze(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.snoozePage(ctx, m, a.raw)
+}
+
+func (p *Processor) hUndo(ctx context.Context, a cmdArgs, m Meta) error {
+	text, err := p.undo(ctx, m, 0)
+	if err != nil {
+		return err
+	}
+
+	return p.tg.SendMessage(ctx, m.Chat.ID, text)
+}
+
+func (p *Processor) hGroup(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.groupList(ctx, m, a.choice)
+}
+
+func (p *Processor) hSettings(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.sendSettings(ctx, m)
+}
+
+func (p *Processor) hLang(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.changeLang(ctx, m, a.choice)
+}
+
+// hCancel is reached only after doFlow has ended the conversation, if there was one.
+func (p *Processor) hCancel(ctx context.Context, a cmdArgs, m Meta) error {
+	return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgCancelled))
+}
+
+func (p *Processor) cbUndo(ctx context.Context, data string, m Meta) error {
+	pageID, err := strconv.ParseInt(data, 10, 64)
+	if err != nil || pageID <= 0 {
+		return p.tg.AnswerCallbackQuery(ctx, m.CallbackID, "")
+	}
+
+	text, err := p.undo(ctx, m, pageID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.tg.AnswerCallbackQuery(ctx, m.CallbackID, text); err != nil {
+		return err
+	}
+
+	return p.tg.EditMessageText(ctx, m.Chat.ID, m.MessageID, text, nil)
+}
+
+func (p *Processor) cbBulkDelete(ctx context.Context, data string, m Meta) error {
+	token, answer, _ := strings.Cut(data, ":")
+
+	text := m.t(msgCancelled)
+	if answer == "yes" {
+		var err error
+		if text, err = p.bulkDelete(ctx, m, token); err != nil {
+			return err
+		}
+	}
+
+	if err := p.tg.AnswerCallbackQuery(ctx, m.CallbackID, ""); err != nil {
+		return err
+	}
+
+	return p.tg.EditMessageText(ctx, m.Chat.ID, m.MessageID, text, nil)
+}
+
+func (p *Processor) cbFlow(ctx context.Context, data string, m Meta) error {
+	c, err := p.conversation(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	if c == nil {
+		return p.tg.AnswerCallbackQuery(ctx, m.CallbackID, m.t(msgConfirmationExpired))
+	}
+
+	if err := p.tg.AnswerCallbackQuery(ctx, m.CallbackID, ""); err != nil {
+		return err
+	}
+
+	return p.continueFlow(ctx, c, data, m)
+}
+
+func (p *Processor) cbSnooze(ctx context.Context, data string, m Meta) error {
+	pageID, err := strconv.ParseInt(data, 10, 64)
+	if err != nil || pageID <= 0 {
+		return p.tg.AnswerCallbackQuery(ctx, m.CallbackID, "")
+	}
+
+	text, err := p.notNow(ctx, m, pageID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.tg.AnswerCallbackQuery(ctx, m.CallbackID, text); err != nil {
+		return err
+	}
+
+	return p.tg.EditMessageText(ctx, m.Chat.ID, m.MessageID, text, nil)
+}
+
+// cbPin handles the star button under "Saved!": "pin:<pageID>:1|0".
+func (p *Processor) cbPin(ctx context.Context, data string, m Meta) error {
+	id, state, _ := strings.Cut(data, ":")
+
+	pageID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || pageID <= 0 {
+		return p.tg.AnswerCallbackQuery(ctx, m.CallbackID, "")
+	}
+	pinned := state == "1"
+
+	err = p.storage.SetPinned(ctx, m.OwnerID, pageID, pinned)
+	if errors.Is(err, storage.ErrNotFound) {
+		return p.tg.AnswerCallbackQuery(ctx, m.CallbackID, m.t(msgPageGone))
+	}
+	if err != nil {
+		return e.Wrap("Commands: can't pin page", err)
+	}
+
+	if err := p.tg.AnswerCallbackQuery(ctx, m.CallbackID, ""); err != nil {
+		return err
+	}
+
+	kb := pinKeyboard(m.Lang, pageID, pinned)
+
+	return p.tg.EditMessageText(ctx, m.Chat.ID, m.MessageID, m.t(msgSaved), &kb)
+}
+
+// cbBroken handles buttons of the broken links report: "broken:del" and "broken:keep".
+func (p *Processor) cbBroken(ctx context.Context, data string, m Meta) error {
+	if isGroupList(m) {
+		ok, err := p.requireAdmin(ctx, m)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	text := m.t(msgBrokenKept)
+	if data == "del" {
+		var err error
+		if text, err = p.removeBroken(ctx, m); err != nil {
+			return err
+		}
+	}
+
+	if err := p.tg.AnswerCallbackQuery(ctx, m.CallbackID, ""); err != nil {
+		return err
+	}
+
+	return p.tg.EditMessageText(ctx, m.Chat.ID, m.MessageID, text, nil)
 }
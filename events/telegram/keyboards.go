@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"narasla_bot/clients/telegram"
+	"narasla_bot/lib/i18n"
+	"strconv"
+)
+
+func undoKeyboard(lang i18n.Lang, pageID int64) telegram.InlineKeyboardMarkup {
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{
+				Text:         catalog.T(lang, msgUndoButton),
+				CallbackData: undoCallback + ":" + strconv.FormatInt(pageID, 10),
+			},
+		}},
+	}
+}
+
+// pinKeyboard is the star button under "Saved!", it flips the pinned state.
+func pinKeyboard(lang i18n.Lang, pageID int64, pinned bool) telegram.InlineKeyboardMarkup {
+	text, next := catalog.T(lang, msgPinButton), "1"
+	if pinned {
+		text, next = catalog.T(lang, msgPinnedButton), "0"
+	}
+
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{
+				Text:         text,
+				CallbackData: pinCallback + ":" + strconv.FormatInt(pageID, 10) + ":" + next,
+			},
+		}},
+	}
+}
+
+// confirmKeyboard has Yes/No buttons with "<prefix>:<token>:yes|no" data.
+func confirmKeyboard(lang i18n.Lang, prefix, token string) telegram.InlineKeyboardMarkup {
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{Text: catalog.T(lang, msgYesButton), CallbackData: prefix + ":" + token + ":yes"},
+			{Text: catalog.T(lang, msgNoButton), CallbackData: prefix + ":" + token + ":no"},
+		}},
+	}
+}
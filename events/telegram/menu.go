@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"context"
+	"narasla_bot/clients/telegram"
+	"narasla_bot/lib/e"
+	"narasla_bot/lib/i18n"
+	"strings"
+)
+
+var menuScopes = map[scope]telegram.BotCommandScope{
+	scopePrivate: {Type: "all_private_chats"},
+	scopeGroup:   {Type: "all_group_chats"},
+}
+
+// PublishCommands sets the Telegram command menu from the handler registry
+// for every scope and language, so the menu always matches the handlers.
+func (p *Processor) PublishCommands(ctx context.Context) error {
+	for sc, tgScope := range menuScopes {
+		for _, lang := range i18n.Langs {
+			cmds := p.menu(sc, lang)
+
+			if err := p.tg.SetMyCommands(ctx, cmds, tgScope, string(lang)); err != nil {
+				return e.Wrap("Commands: can't publish commands", err)
+			}
+
+			// users with other languages get the default one.
+			if lang != i18n.Default {
+				continue
+			}
+			if err := p.tg.SetMyCommands(ctx, cmds, tgScope, ""); err != nil {
+				return e.Wrap("Commands: can't publish commands", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Processor) menu(sc scope, lang i18n.Lang) []telegram.BotCommand {
+	cmds := make([]telegram.BotCommand, 0, len(p.commands))
+
+	for _, c := range p.commands {
+		if c.scopes&sc == 0 || c.noMenu {
+			continue
+		}
+
+		cmds = append(cmds, telegram.BotCommand{
+			Command:     strings.TrimPrefix(c.name, "/"),
+			Description: catalog.T(lang, c.description, c.descArgs...),
+		})
+	}
+
+	return cmds
+}
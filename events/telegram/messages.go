@@ -1,39 +1,422 @@
 package telegram
 
-const msgHelp = `I'm a simple “save now, read later” bot.
+import "narasla_bot/lib/i18n"
+
+const (
+	msgHelpIntro            i18n.Key = "help_intro"
+	msgHelpCommands         i18n.Key = "help_commands"
+	msgHelpFooter           i18n.Key = "help_footer"
+	msgHelpAliases          i18n.Key = "help_aliases"
+	msgUsage                i18n.Key = "usage"
+	msgHello                i18n.Key = "hello"
+	msgUnknownCommand       i18n.Key = "unknown_command"
+	msgNoSavedPages         i18n.Key = "no_saved_pages"
+	msgSaved                i18n.Key = "saved"
+	msgNoteSaved            i18n.Key = "note_saved"
+	msgAlreadyExists        i18n.Key = "already_exists"
+	msgDeleted              i18n.Key = "deleted"
+	msgRestored             i18n.Key = "restored"
+	msgRestoredPages        i18n.Key = "restored_pages"
+	msgNothingToUndo        i18n.Key = "nothing_to_undo"
+	msgUndoButton           i18n.Key = "undo_button"
+	msgYesButton            i18n.Key = "yes_button"
+	msgNoButton             i18n.Key = "no_button"
+	msgCancelled            i18n.Key = "cancelled"
+	msgAskLink              i18n.Key = "ask_link"
+	msgConfirmationExpired  i18n.Key = "confirmation_expired"
+	msgConfirmBulkDelete    i18n.Key = "confirm_bulk_delete"
+	msgBulkDeleted          i18n.Key = "bulk_deleted"
+	msgOnlyItems            i18n.Key = "only_items"
+	msgListHeader           i18n.Key = "list_header"
+	msgGroupListHeader      i18n.Key = "group_list_header"
+	msgListFooter           i18n.Key = "list_footer"
+	msgFilteredListFooter   i18n.Key = "filtered_list_footer"
+	msgIncorrectFilter      i18n.Key = "incorrect_filter"
+	msgSettings             i18n.Key = "settings"
+	msgSettingAutopush      i18n.Key = "setting_autopush"
+	msgSettingTimezone      i18n.Key = "setting_timezone"
+	msgSettingWindow        i18n.Key = "setting_window"
+	msgSettingAfterDelivery i18n.Key = "setting_after_delivery"
+	msgSettingLang          i18n.Key = "setting_lang"
+	msgSettingStrategy      i18n.Key = "setting_strategy"
+	msgStrategyRandom       i18n.Key = "strategy_random"
+	msgStrategyOldest       i18n.Key = "strategy_oldest"
+	msgStrategyNewest       i18n.Key = "strategy_newest"
+	msgStrategyWeighted     i18n.Key = "strategy_weighted"
+	msgStrategyRoundRobin   i18n.Key = "strategy_roundrobin"
+	msgStrategyTagTurns     i18n.Key = "strategy_tags"
+	msgAfterDeliveryDelete  i18n.Key = "after_delivery_delete"
+	msgAfterDeliveryKeep    i18n.Key = "after_delivery_keep"
+	msgOn                   i18n.Key = "on"
+	msgOff                  i18n.Key = "off"
+	msgLangName             i18n.Key = "lang_name"
+	msgBackButton           i18n.Key = "back_button"
+	msgAskTimezone          i18n.Key = "ask_timezone"
+	msgIncorrectTimezone    i18n.Key = "incorrect_timezone"
+	msgTimezoneChanged      i18n.Key = "timezone_changed"
+	msgSnoozed              i18n.Key = "snoozed"
+	msgSnoozedLabel         i18n.Key = "snoozed_label"
+	msgPageGone             i18n.Key = "page_gone"
+	msgPinButton            i18n.Key = "pin_button"
+	msgPinnedButton         i18n.Key = "pinned_button"
+	msgPinned               i18n.Key = "pinned"
+	msgUnpinned             i18n.Key = "unpinned"
+	msgReadMinutes          i18n.Key = "read_minutes"
+	msgBrokenKept           i18n.Key = "broken_kept"
+	msgNoSnapshot           i18n.Key = "no_snapshot"
+	msgKindVideo            i18n.Key = "kind_video"
+	msgKindPDF              i18n.Key = "kind_pdf"
+	msgKindRepo             i18n.Key = "kind_repo"
+	msgKindTweet            i18n.Key = "kind_tweet"
+	msgNothingMatches       i18n.Key = "nothing_matches"
+	msgAutopushTurnedOff    i18n.Key = "autopush_off"
+	msgAutopushTurnedOn     i18n.Key = "autopush_on"
+	msgUnknownUser          i18n.Key = "unknown_user"
+	msgGroupOnly            i18n.Key = "group_only"
+	msgPrivateOnly          i18n.Key = "private_only"
+	msgAdminsOnly           i18n.Key = "admins_only"
+	msgGroupListOn          i18n.Key = "group_list_on"
+	msgGroupListOff         i18n.Key = "group_list_off"
+	msgLangChanged          i18n.Key = "lang_changed"
+	msgLangStatus           i18n.Key = "lang_status"
+
+	msgCmdSave       i18n.Key = "cmd_save"
+	msgCmdRnd        i18n.Key = "cmd_rnd"
+	msgCmdList       i18n.Key = "cmd_list"
+	msgCmdDel        i18n.Key = "cmd_del"
+	msgCmdUndo       i18n.Key = "cmd_undo"
+	msgCmdAutopush   i18n.Key = "cmd_autopush"
+	msgCmdGroup      i18n.Key = "cmd_group"
+	msgCmdLang       i18n.Key = "cmd_lang"
+	msgCmdHelp       i18n.Key = "cmd_help"
+	msgCmdStart      i18n.Key = "cmd_start"
+	msgCmdCancel     i18n.Key = "cmd_cancel"
+	msgCmdSettings   i18n.Key = "cmd_settings"
+	msgCmdSnooze     i18n.Key = "cmd_snooze"
+	msgCmdSavePinned i18n.Key = "cmd_save_pinned"
+	msgCmdPin        i18n.Key = "cmd_pin"
+	msgCmdUnpin      i18n.Key = "cmd_unpin"
+	msgCmdRead       i18n.Key = "cmd_read"
+
+	msgUsageSave       i18n.Key = "usage_save"
+	msgUsageRnd        i18n.Key = "usage_rnd"
+	msgUsageList       i18n.Key = "usage_list"
+	msgUsageDel        i18n.Key = "usage_del"
+	msgUsageUndo       i18n.Key = "usage_undo"
+	msgUsageAutopush   i18n.Key = "usage_autopush"
+	msgUsageGroup      i18n.Key = "usage_group"
+	msgUsageLang       i18n.Key = "usage_lang"
+	msgUsageHelp       i18n.Key = "usage_help"
+	msgUsageStart      i18n.Key = "usage_start"
+	msgUsageCancel     i18n.Key = "usage_cancel"
+	msgUsageSettings   i18n.Key = "usage_settings"
+	msgUsageSnooze     i18n.Key = "usage_snooze"
+	msgUsageSavePinned i18n.Key = "usage_save_pinned"
+	msgUsagePin        i18n.Key = "usage_pin"
+	msgUsageUnpin      i18n.Key = "usage_unpin"
+	msgUsageRead       i18n.Key = "usage_read"
+
+	msgDetailsSave     i18n.Key = "details_save"
+	msgDetailsFilter   i18n.Key = "details_filter"
+	msgDetailsDel      i18n.Key = "details_del"
+	msgDetailsAutopush i18n.Key = "details_autopush"
+	msgDetailsGroup    i18n.Key = "details_group"
+	msgDetailsSnooze   i18n.Key = "details_snooze"
+	msgDetailsRead     i18n.Key = "details_read"
+)
+
+var catalog = i18n.Catalog{
+	i18n.EN: {
+		msgHello: {"Hellooo! :3"},
+		msgHelpIntro: {`I'm a simple “save now, read later” bot.
 
 How to save:
 • In private chat: just send me a link — I'll save it.
-• In group chats: use /save@na_raslabot <link> (so I don't react to random messages).
+• In group chats: use /save@%s <link> (so I don't react to random messages).`},
+		msgHelpCommands:        {"Commands:"},
+		msgHelpFooter:          {"Send /help <command> for details, e.g. /help del"},
+		msgHelpAliases:         {"Aliases: %s"},
+		msgUsage:               {"Usage: %s"},
+		msgUnknownCommand:      {"Unknown command."},
+		msgNoSavedPages:        {"You have no saved pages."},
+		msgSaved:               {"Saved! Reply to this message to add a note."},
+		msgNoteSaved:           {"Note saved."},
+		msgAlreadyExists:       {"You already have this page on your list."},
+		msgDeleted:             {"Page was deleted."},
+		msgRestored:            {"Page was restored: %s"},
+		msgRestoredPages:       {"Restored %d page.", "Restored %d pages."},
+		msgNothingToUndo:       {"Nothing to undo."},
+		msgUndoButton:          {"Undo"},
+		msgYesButton:           {"Yes"},
+		msgNoButton:            {"No"},
+		msgCancelled:           {"Cancelled."},
+		msgAskLink:             {"Send me the link to save, you can add #tags and — a note. /cancel to stop."},
+		msgConfirmationExpired: {"This confirmation has expired. Send the command again."},
+		msgConfirmBulkDelete:   {"Delete %d page?", "Delete %d pages?"},
+		msgBulkDeleted:         {"Deleted %d page, /undo brings it back.", "Deleted %d pages, /undo brings them all back."},
+		msgOnlyItems: {
+			"You have only %d item in the list. Send /del to see it.",
+			"You have only %d items in the list. Send /del to see them.",
+		},
+		msgListHeader:           {"@%s 's saved pages:"},
+		msgGroupListHeader:      {"%s shared list:"},
+		msgListFooter:           {"Delete: /del <number> or /del <url>"},
+		msgFilteredListFooter:   {"Delete: /del <url>"},
+		msgIncorrectFilter:      {"Unknown filter. Use: oldest, newest, unread, pinned, broken, short, long, video, domain:<host>, since:<7d|2w|3m>, #<tag>"},
+		msgNothingMatches:       {"No saved pages match the filter."},
+		msgSettings:             {"Settings. Tap a button to change it:"},
+		msgSettingAutopush:      {"Auto push: %s"},
+		msgSettingTimezone:      {"Timezone: %s"},
+		msgSettingWindow:        {"Delivery time: %s"},
+		msgSettingAfterDelivery: {"After delivery: %s"},
+		msgSettingLang:          {"Language: %s"},
+		msgSettingStrategy:      {"Pick: %s"},
+		msgStrategyRandom:       {"random"},
+		msgStrategyOldest:       {"oldest first"},
+		msgStrategyNewest:       {"newest first"},
+		msgStrategyWeighted:     {"random, older more often"},
+		msgStrategyRoundRobin:   {"sites in turn"},
+		msgStrategyTagTurns:     {"tags in turn"},
+		msgAfterDeliveryDelete:  {"delete the page"},
+		msgAfterDeliveryKeep:    {"keep it as read"},
+		msgOn:                   {"on"},
+		msgOff:                  {"off"},
+		msgLangName:             {"English"},
+		msgBackButton:           {"« Back"},
+		msgAskTimezone:          {"Send your timezone: a city like Europe/Berlin or an offset like +3. /cancel to stop."},
+		msgIncorrectTimezone:    {"I don't know this timezone. Try Europe/Berlin or +3, or send /cancel."},
+		msgTimezoneChanged:      {"Timezone: %s"},
+		msgSnoozed:              {"Snoozed until %s: %s"},
+		msgSnoozedLabel:         {"snoozed until %s"},
+		msgPageGone:             {"This page is no longer on your list."},
+		msgPinButton:            {"☆ Pin"},
+		msgPinnedButton:         {"★ Pinned"},
+		msgPinned:               {"Pinned: %s"},
+		msgUnpinned:             {"Unpinned: %s"},
+		msgReadMinutes:          {"%d min"},
+		msgBrokenKept:           {"OK, the pages stay in your list. /list broken shows them."},
+		msgNoSnapshot:           {"There is no saved copy of this page: %s"},
+		msgKindVideo:            {"video"},
+		msgKindPDF:              {"PDF"},
+		msgKindRepo:             {"repo"},
+		msgKindTweet:            {"tweet"},
+		msgAutopushTurnedOff:    {"Auto push turned off"},
+		msgAutopushTurnedOn:     {"Auto push turned on"},
+		msgUnknownUser:          {"I don't know you yet. Send /start in private chat first"},
+		msgGroupOnly:            {"This command works only in group chats."},
+		msgPrivateOnly:          {"This command works only in private chat."},
+		msgAdminsOnly:           {"Only group admins can change this."},
+		msgGroupListOn:          {"Group list is on: pages saved here go to the shared list of this chat."},
+		msgGroupListOff:         {"Group list is off: everyone saves pages to their own list."},
+		msgLangChanged:          {"Language: English"},
+		msgLangStatus:           {"Language: English. Change it with /lang en | ru"},
 
-Commands:
-• /help — show this message
-• /save <url> — save a link (required in groups)
-• /rnd — send one random saved page and remove it from your list
-• /del — delete a page:
-  - /del            (show your list)
-  - /del <number>   (delete by number from the list)
-  - /del <url>      (delete by exact link)
-• /list — show your saved pages (up to 20)
+		msgCmdSave:       {"Save a link"},
+		msgCmdRnd:        {"Send a random saved page and remove it"},
+		msgCmdList:       {"Show saved pages (up to %d)"},
+		msgCmdDel:        {"Delete pages by number, url or filter"},
+		msgCmdUndo:       {"Restore the last removed pages"},
+		msgCmdAutopush:   {"Daily auto-send of one page"},
+		msgCmdGroup:      {"Shared reading list of the group (admins only)"},
+		msgCmdLang:       {"Change language"},
+		msgCmdHelp:       {"Show help"},
+		msgCmdStart:      {"Start the bot"},
+		msgCmdCancel:     {"Stop the current question"},
+		msgCmdSettings:   {"Auto push, timezone, delivery time, language"},
+		msgCmdSnooze:     {"Hide a page for a while and remind about it later"},
+		msgCmdSavePinned: {"Save a link and pin it"},
+		msgCmdPin:        {"Pin a page: pinned pages are sent first"},
+		msgCmdUnpin:      {"Unpin a page"},
+		msgCmdRead:       {"Read the saved copy of a page"},
 
-Note:
-After /rnd, the sent page is deleted from your list (so you won't get repeats).`
+		msgUsageSave:       {"/save <url> [#tag ...] [— note]"},
+		msgUsageRnd:        {"/rnd [filter]"},
+		msgUsageList:       {"/list [filter]"},
+		msgUsageDel:        {"/del [<number> | <url> | 1-5,8,12 | domain:<host> | older:<180d> | all]"},
+		msgUsageUndo:       {"/undo"},
+		msgUsageAutopush:   {"/autopush [on | off | status]"},
+		msgUsageGroup:      {"/group [on | off | status]"},
+		msgUsageLang:       {"/lang [en | ru]"},
+		msgUsageHelp:       {"/help [command]"},
+		msgUsageStart:      {"/start"},
+		msgUsageCancel:     {"/cancel"},
+		msgUsageSettings:   {"/settings"},
+		msgUsageSnooze:     {"/snooze <number> <30m | 12h | 3d | 2w>"},
+		msgUsageSavePinned: {"/save! <url> [#tag ...] [— note]"},
+		msgUsagePin:        {"/pin <number>"},
+		msgUsageUnpin:      {"/unpin <number>"},
+		msgUsageRead:       {"/read <number> [html]"},
 
-// TODO: add method for changing saving logic -- deleting and not deleting.
-// TODO: add method for changing language ru/eng.
+		msgDetailsSave: {`In private chat you can just send a link.
+Tag a page when saving it: /save <url> #go #later
+Add a note: /save <url> — why it matters, or reply to my "Saved!" message with the note text.
+Pin an important page: /save! <url>, the star button under "Saved!" or /pin <number>. /rnd and autopush send pinned pages first.`},
+		msgDetailsFilter: {`Filters can be combined: oldest, newest, unread, pinned, broken, domain:github.com, since:7d, #tag
+By content: short (up to 5 min), long (20 min and more), article, video, pdf, repo, tweet
+After /rnd the sent page is deleted from your list, so you won't get repeats.
+With "After delivery: keep it as read" in /settings it stays in /list as read, and /rnd skips it.`},
+		msgDetailsDel: {`• /del — show your list
+• /del <number> — delete by number from the list
+• /del <url> — delete by exact link
+• /del 1-5,8,12 — delete several by numbers
+• /del domain:twitter.com, /del older:180d, /del all — asks for confirmation
+/undo restores the last deletion, all pages of a bulk delete at once.`},
+		msgDetailsAutopush: {"Without argument it toggles auto push. In a group with the shared list only admins can change it."},
+		msgDetailsSnooze: {`The number is the one in /list, the time is in minutes, hours, days or weeks: 30m, 12h, 3d, 2w.
+A snoozed page isn't sent by /rnd and autopush; when the time comes, the bot reminds you about it.
+Pages sent by autopush have a "Not now" button that snoozes them for a day.`},
+		msgDetailsRead: {`The number is the one in /list. The bot keeps the text of articles when they are saved,
+so you can read them even if the page is changed or gone. Long texts and /read <number> html come as an HTML file.`},
+		msgDetailsGroup: {`After /group on in a group, /save, /list, /rnd, /del and /undo there work with the group's shared list,
+and /autopush (admins only) posts one page a day to the group.`},
+	},
+	i18n.RU: {
+		msgHello: {"Приве-е-ет! :3"},
+		msgHelpIntro: {`Я простой бот «сохрани сейчас, прочитай потом».
 
-const msgHello = "Hellooo! :3\n\n" + msgHelp
+Как сохранить:
+• В личном чате: просто пришли мне ссылку — я её сохраню.
+• В группах: используй /save@%s <ссылка> (чтобы я не реагировал на случайные сообщения).`},
+		msgHelpCommands:        {"Команды:"},
+		msgHelpFooter:          {"Подробнее о команде: /help <команда>, например /help del"},
+		msgHelpAliases:         {"Другие названия: %s"},
+		msgUsage:               {"Использование: %s"},
+		msgUnknownCommand:      {"Неизвестная команда."},
+		msgNoSavedPages:        {"У тебя нет сохранённых страниц."},
+		msgSaved:               {"Сохранено! Ответь на это сообщение, чтобы добавить заметку."},
+		msgNoteSaved:           {"Заметка сохранена."},
+		msgAlreadyExists:       {"Эта страница уже есть в твоём списке."},
+		msgDeleted:             {"Страница удалена."},
+		msgRestored:            {"Страница восстановлена: %s"},
+		msgRestoredPages:       {"Восстановлена %d страница.", "Восстановлено %d страницы.", "Восстановлено %d страниц."},
+		msgNothingToUndo:       {"Нечего отменять."},
+		msgUndoButton:          {"Отменить"},
+		msgYesButton:           {"Да"},
+		msgNoButton:            {"Нет"},
+		msgCancelled:           {"Отменено."},
+		msgAskLink:             {"Пришли ссылку для сохранения, можно с #тегами и — заметкой. /cancel, чтобы прервать."},
+		msgConfirmationExpired: {"Подтверждение устарело. Отправь команду ещё раз."},
+		msgConfirmBulkDelete:   {"Удалить %d страницу?", "Удалить %d страницы?", "Удалить %d страниц?"},
+		msgBulkDeleted: {
+			"Удалена %d страница, /undo её вернёт.",
+			"Удалено %d страницы, /undo вернёт их все.",
+			"Удалено %d страниц, /undo вернёт их все.",
+		},
+		msgOnlyItems: {
+			"В списке всего %d страница. Отправь /del, чтобы её увидеть.",
+			"В списке всего %d страницы. Отправь /del, чтобы их увидеть.",
+			"В списке всего %d страниц. Отправь /del, чтобы их увидеть.",
+		},
+		msgListHeader:           {"Сохранённые страницы @%s:"},
+		msgGroupListHeader:      {"Общий список %s:"},
+		msgListFooter:           {"Удалить: /del <номер> или /del <url>"},
+		msgFilteredListFooter:   {"Удалить: /del <url>"},
+		msgIncorrectFilter:      {"Неизвестный фильтр. Доступны: oldest, newest, unread, pinned, broken, short, long, video, domain:<сайт>, since:<7d|2w|3m>, #<тег>"},
+		msgNothingMatches:       {"Нет страниц, подходящих под фильтр."},
+		msgSettings:             {"Настройки. Нажми на кнопку, чтобы изменить:"},
+		msgSettingAutopush:      {"Автоотправка: %s"},
+		msgSettingTimezone:      {"Часовой пояс: %s"},
+		msgSettingWindow:        {"Время доставки: %s"},
+		msgSettingAfterDelivery: {"После доставки: %s"},
+		msgSettingLang:          {"Язык: %s"},
+		msgSettingStrategy:      {"Выбор: %s"},
+		msgStrategyRandom:       {"случайно"},
+		msgStrategyOldest:       {"сначала старые"},
+		msgStrategyNewest:       {"сначала новые"},
+		msgStrategyWeighted:     {"случайно, старые чаще"},
+		msgStrategyRoundRobin:   {"сайты по очереди"},
+		msgStrategyTagTurns:     {"теги по очереди"},
+		msgAfterDeliveryDelete:  {"удалять страницу"},
+		msgAfterDeliveryKeep:    {"оставлять прочитанной"},
+		msgOn:                   {"вкл"},
+		msgOff:                  {"выкл"},
+		msgLangName:             {"Русский"},
+		msgBackButton:           {"« Назад"},
+		msgAskTimezone:          {"Пришли часовой пояс: город вроде Europe/Moscow или смещение вроде +3. /cancel, чтобы прервать."},
+		msgIncorrectTimezone:    {"Не знаю такой часовой пояс. Попробуй Europe/Moscow или +3, или отправь /cancel."},
+		msgTimezoneChanged:      {"Часовой пояс: %s"},
+		msgSnoozed:              {"Отложено до %s: %s"},
+		msgSnoozedLabel:         {"отложено до %s"},
+		msgPageGone:             {"Этой страницы уже нет в списке."},
+		msgPinButton:            {"☆ Закрепить"},
+		msgPinnedButton:         {"★ Закреплено"},
+		msgPinned:               {"Закреплено: %s"},
+		msgUnpinned:             {"Откреплено: %s"},
+		msgReadMinutes:          {"%d мин"},
+		msgBrokenKept:           {"Хорошо, страницы останутся в списке. /list broken покажет их."},
+		msgNoSnapshot:           {"Сохранённой копии этой страницы нет: %s"},
+		msgKindVideo:            {"видео"},
+		msgKindPDF:              {"PDF"},
+		msgKindRepo:             {"репозиторий"},
+		msgKindTweet:            {"твит"},
+		msgAutopushTurnedOff:    {"Автоотправка выключена"},
+		msgAutopushTurnedOn:     {"Автоотправка включена"},
+		msgUnknownUser:          {"Я тебя ещё не знаю. Сначала отправь /start в личном чате"},
+		msgGroupOnly:            {"Эта команда работает только в группах."},
+		msgPrivateOnly:          {"Эта команда работает только в личном чате."},
+		msgAdminsOnly:           {"Это могут менять только админы группы."},
+		msgGroupListOn:          {"Общий список включён: страницы, сохранённые здесь, попадают в список группы."},
+		msgGroupListOff:         {"Общий список выключен: каждый сохраняет страницы в свой список."},
+		msgLangChanged:          {"Язык: русский"},
+		msgLangStatus:           {"Язык: русский. Сменить: /lang en | ru"},
 
-const (
-	msgUnknownCommand     = "Unknown command."
-	msgNoSavedPages       = "You have no saved pages."
-	msgSaved              = "Saved!"
-	msgAlreadyExists      = "You already have this page on your list."
-	msgDeleted            = "Page was deleted."
-	msgIncorrectDeleteArg = "Usage: /del or /del <number> or /del <url>"
-	msgIncorrectSave      = "Usage: /save <url>"
-	msgAutopushTurnedOff  = "Auto push turned off"
-	msgAutopushTurnedOn   = "Auto push turned on"
-	msgIncorrectAutopush  = "Usage: /autopush on | off or nothing to toggle"
-	msgUnknownUser        = "I don't know you yet. Send /start in private chat first"
-)
+		msgCmdSave:       {"Сохранить ссылку"},
+		msgCmdRnd:        {"Прислать случайную страницу и удалить её"},
+		msgCmdList:       {"Показать сохранённые страницы (до %d)"},
+		msgCmdDel:        {"Удалить страницы по номеру, ссылке или фильтру"},
+		msgCmdUndo:       {"Вернуть последние удалённые страницы"},
+		msgCmdAutopush:   {"Ежедневная отправка одной страницы"},
+		msgCmdGroup:      {"Общий список группы (только админы)"},
+		msgCmdLang:       {"Сменить язык"},
+		msgCmdHelp:       {"Показать справку"},
+		msgCmdStart:      {"Запустить бота"},
+		msgCmdCancel:     {"Прервать текущий вопрос"},
+		msgCmdSettings:   {"Автоотправка, часовой пояс, время доставки, язык"},
+		msgCmdSnooze:     {"Отложить страницу и напомнить о ней позже"},
+		msgCmdSavePinned: {"Сохранить ссылку и закрепить её"},
+		msgCmdPin:        {"Закрепить страницу: закреплённые присылаются первыми"},
+		msgCmdUnpin:      {"Открепить страницу"},
+		msgCmdRead:       {"Прочитать сохранённую копию страницы"},
+
+		msgUsageSave:       {"/save <url> [#тег ...] [— заметка]"},
+		msgUsageRnd:        {"/rnd [фильтр]"},
+		msgUsageList:       {"/list [фильтр]"},
+		msgUsageDel:        {"/del [<номер> | <url> | 1-5,8,12 | domain:<сайт> | older:<180d> | all]"},
+		msgUsageUndo:       {"/undo"},
+		msgUsageAutopush:   {"/autopush [on | off | status]"},
+		msgUsageGroup:      {"/group [on | off | status]"},
+		msgUsageLang:       {"/lang [en | ru]"},
+		msgUsageStart:      {"/start"},
+		msgUsageCancel:     {"/cancel"},
+		msgUsageSettings:   {"/settings"},
+		msgUsageSnooze:     {"/snooze <номер> <30m | 12h | 3d | 2w>"},
+		msgUsageSavePinned: {"/save! <url> [#тег ...] [— заметка]"},
+		msgUsagePin:        {"/pin <номер>"},
+		msgUsageUnpin:      {"/unpin <номер>"},
+		msgUsageRead:       {"/read <номер> [html]"},
+		msgUsageHelp:       {"/help [команда]"},
+
+		msgDetailsSave: {`В личном чате можно просто прислать ссылку.
+Теги при сохранении: /save <url> #go #later
+Заметка: /save <url> — зачем это читать, или ответь на моё сообщение «Сохранено!» текстом заметки.
+Закрепить важную страницу: /save! <url>, звёздочка под «Сохранено!» или /pin <номер>. /rnd и автоотправка присылают закреплённые первыми.`},
+		msgDetailsFilter: {`Фильтры можно сочетать: oldest, newest, unread, pinned, broken, domain:github.com, since:7d, #тег
+По содержимому: short (до 5 мин), long (от 20 мин), article, video, pdf, repo, tweet
+После /rnd отправленная страница удаляется из списка, чтобы не было повторов.
+С «После доставки: оставлять прочитанной» в /settings она остаётся в /list прочитанной, и /rnd её пропускает.`},
+		msgDetailsDel: {`• /del — показать список
+• /del <номер> — удалить по номеру из списка
+• /del <url> — удалить по точной ссылке
+• /del 1-5,8,12 — удалить несколько по номерам
+• /del domain:twitter.com, /del older:180d, /del all — с подтверждением
+/undo вернёт последнее удаление, все страницы массового удаления сразу.`},
+		msgDetailsAutopush: {"Без аргумента переключает автоотправку. В группе с общим списком менять её могут только админы."},
+		msgDetailsSnooze: {`Номер — из /list, время — в минутах, часах, днях или неделях: 30m, 12h, 3d, 2w.
+Отложенную страницу не пришлют /rnd и автоотправка, а когда время выйдет, бот о ней напомнит.
+Под страницами из автоотправки есть кнопка «Не сейчас» — она откладывает страницу на день.`},
+		msgDetailsRead: {`Номер — из /list. Бот сохраняет текст статей при сохранении ссылки,
+так что их можно прочитать, даже если страница изменилась или пропала. Длинные тексты и /read <номер> html приходят HTML-файлом.`},
+		msgDetailsGroup: {`После /group on в группе команды /save, /list, /rnd, /del и /undo работают с общим списком группы,
+а /autopush (только админы) присылает в группу одну страницу в день.`},
+	},
+}
@@ -0,0 +1,30 @@
+package telegram
+
+import (
+	"testing"
+
+	"narasla_bot/lib/i18n"
+)
+
+// TestCatalog checks that every language has every message, with all plural forms.
+func TestCatalog(t *testing.T) {
+	for key, en := range catalog[i18n.EN] {
+		for _, lang := range i18n.Langs[1:] {
+			msg, ok := catalog[lang][key]
+			switch {
+			case !ok:
+				t.Errorf("%s: %s is missing", lang, key)
+			case len(en) > 1 && len(msg) < 3:
+				t.Errorf("%s: %s has %d plural forms, want one, few, many", lang, key, len(msg))
+			}
+		}
+	}
+
+	for _, lang := range i18n.Langs {
+		for key := range catalog[lang] {
+			if _, ok := catalog[i18n.EN][key]; !ok {
+				t.Errorf("%s: %s isn't in the English catalog", lang, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"narasla_bot/storage"
+	"sync"
+	"time"
+)
+
+// pendingTTL is how long a confirmation button stays valid.
+const pendingTTL = 10 * time.Minute
+
+// pendingDelete is a bulk delete waiting for the user to press Yes.
+type pendingDelete struct {
+	ownerID int64
+	userID  int64 // who asked, only they can confirm
+	filter  storage.Filter
+	expires time.Time
+}
+
+// pendingDeletes keeps confirmations in memory: they are short-lived,
+// and after a restart the user can just send the command again.
+type pendingDeletes struct {
+	mu    sync.Mutex
+	items map[string]pendingDelete
+}
+
+func newPendingDeletes() *pendingDeletes {
+	return &pendingDeletes{items: make(map[string]pendingDelete)}
+}
+
+func (p *pendingDeletes) add(ownerID, userID int64, f storage.Filter, now time.Time) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for t, item := range p.items {
+		if now.After(item.expires) {
+			delete(p.items, t)
+		}
+	}
+
+	p.items[token] = pendingDelete{
+		ownerID: ownerID,
+		userID:  userID,
+		filter:  f,
+		expires: now.Add(pendingTTL),
+	}
+
+	return token, nil
+}
+
+// take removes the pending delete, so it can't be confirmed twice.
+func (p *pendingDeletes) take(token string, userID int64, now time.Time) (pendingDelete, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	item, ok := p.items[token]
+	if !ok || item.userID != userID {
+		return pendingDelete{}, false
+	}
+	delete(p.items, token)
+
+	if now.After(item.expires) {
+		return pendingDelete{}, false
+	}
+
+	return item, true
+}
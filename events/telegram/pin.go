@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"context"
+	"narasla_bot/lib/e"
+	"narasla_bot/storage"
+	"strconv"
+	"strings"
+)
+
+// pinPage pins or unpins a page by its number in /list: /pin <number>, /unpin <number>.
+func (p *Processor) pinPage(ctx context.Context, m Meta, arg string, pinned bool) (err error) {
+	defer func() { err = e.Wrap("Commands: can't pin page", err) }()
+
+	sendMsg := newMessageSender(ctx, m, p.tg)
+	sendMsgN := newPluralMessageSender(ctx, m, p.tg)
+
+	cmd := PinCmd
+	if !pinned {
+		cmd = UnpinCmd
+	}
+
+	num, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || num <= 0 {
+		return p.sendUsage(ctx, m, cmd)
+	}
+
+	list, err := p.storage.List(ctx, m.OwnerID, m.Username, storage.Filter{}, p.cfg.ListLimit, 0)
+	if err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		return sendMsg(msgNoSavedPages)
+	}
+
+	if num > len(list) {
+		return sendMsgN(msgOnlyItems, len(list))
+	}
+
+	page := list[num-1]
+	if err := p.storage.SetPinned(ctx, m.OwnerID, page.ID, pinned); err != nil {
+		return err
+	}
+
+	if pinned {
+		return sendMsg(msgPinned, page.URL)
+	}
+	return sendMsg(msgUnpinned, page.URL)
+}
@@ -0,0 +1,192 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"narasla_bot/lib/e"
+	"narasla_bot/storage"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxMessageLen is a bit less than the Telegram limit of 4096 characters.
+	maxMessageLen = 4000
+
+	// maxReadMessages: longer snapshots are sent as an HTML file instead of a wall of messages.
+	maxReadMessages = 10
+)
+
+// readPage sends the saved copy of a page of /list: /read <number> [html].
+func (p *Processor) readPage(ctx context.Context, m Meta, arg string) (err error) {
+	defer func() { err = e.Wrap("Commands: can't read page", err) }()
+
+	sendMsg := newMessageSender(ctx, m, p.tg)
+	sendMsgN := newPluralMessageSender(ctx, m, p.tg)
+
+	fields := strings.Fields(strings.ToLower(arg))
+	if len(fields) == 0 || len(fields) > 2 || len(fields) == 2 && fields[1] != "html" {
+		return p.sendUsage(ctx, m, ReadCmd)
+	}
+	asFile := len(fields) == 2
+
+	num, err := strconv.Atoi(fields[0])
+	if err != nil || num <= 0 {
+		return p.sendUsage(ctx, m, ReadCmd)
+	}
+
+	list, err := p.storage.List(ctx, m.OwnerID, m.Username, storage.Filter{}, p.cfg.ListLimit, 0)
+	if err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		return sendMsg(msgNoSavedPages)
+	}
+
+	if num > len(list) {
+		return sendMsgN(msgOnlyItems, len(list))
+	}
+
+	page := list[num-1]
+
+	snap, err := p.storage.Snapshot(ctx, m.OwnerID, page.ID)
+	if errors.Is(err, storage.ErrNoSnapshot) {
+		return sendMsg(msgNoSnapshot, page.URL)
+	}
+	if err != nil {
+		return err
+	}
+
+	chunks := splitMessage(snapshotText(snap, page.URL), maxMessageLen)
+
+	if asFile || len(chunks) > maxReadMessages {
+		return p.tg.SendDocument(ctx, m.Chat.ID, snapshotFileName(snap), snapshotHTML(snap, page.URL), page.URL)
+	}
+
+	for _, chunk := range chunks {
+		if err := p.tg.SendMessage(ctx, m.Chat.ID, chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func snapshotText(snap *storage.Snapshot, pageURL string) string {
+	if snap.Title == "" {
+		return pageURL + "\n\n" + snap.Text
+	}
+
+	return snap.Title + "\n" + pageURL + "\n\n" + snap.Text
+}
+
+// snapshotHTML is a standalone page that opens in any browser or Telegram's viewer.
+func snapshotHTML(snap *storage.Snapshot, pageURL string) []byte {
+	var sb strings.Builder
+
+	title := html.EscapeString(snap.Title)
+	if title == "" {
+		title = html.EscapeString(pageURL)
+	}
+
+	sb.WriteString("<!doctype html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	sb.WriteString("<title>" + title + "</title>\n")
+	sb.WriteString("<style>body{max-width:40em;margin:2em auto;padding:0 1em;font:18px/1.6 Georgia,serif}</style>\n")
+	sb.WriteString("</head>\n<body>\n")
+	sb.WriteString("<h1>" + title + "</h1>\n")
+	sb.WriteString(fmt.Sprintf("<p><a href=\"%s\">%s</a></p>\n", html.EscapeString(pageURL), html.EscapeString(pageURL)))
+
+	for _, paragraph := range strings.Split(snap.Text, "\n\n") {
+		sb.WriteString("<p>" + html.EscapeString(paragraph) + "</p>\n")
+	}
+
+	sb.WriteString("</body>\n</html>\n")
+
+	return []byte(sb.String())
+}
+
+// snapshotFileName makes a file name from the title, only letters, digits and dashes.
+func snapshotFileName(snap *storage.Snapshot) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return r
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			return '-'
+		}
+		return -1
+	}, snap.Title)
+
+	for strings.Contains(name, "--") {
+		name = strings.ReplaceAll(name, "--", "-")
+	}
+
+	name = strings.Trim(name, "-")
+	if utf8.RuneCountInString(name) > 60 {
+		name = strings.TrimRight(string([]rune(name)[:60]), "-")
+	}
+
+	if name == "" {
+		name = "page-" + strconv.FormatInt(snap.PageID, 10)
+	}
+
+	return name + ".html"
+}
+
+// splitMessage splits the text into messages of at most limit characters,
+// between paragraphs where possible, then between words.
+func splitMessage(text string, limit int) []string {
+	var chunks []string
+	var cur strings.Builder
+
+	flush := func() {
+		if cur.Len() > 0 {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		for _, part := range splitLong(paragraph, limit) {
+			size := utf8.RuneCountInString(cur.String())
+			if size > 0 && size+2+utf8.RuneCountInString(part) > limit {
+				flush()
+			}
+
+			if cur.Len() > 0 {
+				cur.WriteString("\n\n")
+			}
+			cur.WriteString(part)
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// splitLong cuts a paragraph longer than limit at spaces, or anywhere if there are none.
+func splitLong(paragraph string, limit int) []string {
+	var parts []string
+
+	runes := []rune(paragraph)
+	for len(runes) > limit {
+		cut := limit
+		for i := limit; i > limit/2; i-- {
+			if unicode.IsSpace(runes[i]) {
+				cut = i
+				break
+			}
+		}
+
+		parts = append(parts, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+
+	return append(parts, string(runes))
+}
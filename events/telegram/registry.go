@@ -0,0 +1,176 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"narasla_bot/lib/i18n"
+	"narasla_bot/storage"
+	"slices"
+	"strings"
+	"time"
+)
+
+// command is an entry of the command registry: the router, /help and the
+// Telegram command menu are all built from it.
+type command struct {
+	name        string
+	aliases     []string
+	handler     handler
+	description i18n.Key
+	descArgs    []any // arguments of description
+	usage       i18n.Key
+	details     i18n.Key // extra text of /help <command>, optional
+	scopes      scope    // chats where the command works and is shown in the menu
+	args        argSpec
+	noMenu      bool // Telegram menu takes only [a-z0-9_] names
+}
+
+type scope int
+
+const (
+	scopePrivate scope = 1 << iota
+	scopeGroup
+
+	scopeAll = scopePrivate | scopeGroup
+)
+
+type argKind int
+
+const (
+	argNone   argKind = iota // command takes no arguments
+	argText                  // free text, the handler parses it
+	argChoice                // one word of argSpec.choices
+	argFilter                // page filter, see parseFilter
+)
+
+type argSpec struct {
+	kind     argKind
+	required bool
+	choices  []string // for argChoice
+}
+
+// cmdArgs is a command argument checked against the argSpec of the command.
+type cmdArgs struct {
+	raw    string
+	choice string         // lower-cased, for argChoice
+	filter storage.Filter // for argFilter
+}
+
+var ErrBadArgs = errors.New("bad command arguments")
+
+func (s argSpec) parse(raw string, now time.Time) (cmdArgs, error) {
+	a := cmdArgs{raw: strings.TrimSpace(raw)}
+
+	if a.raw == "" {
+		if s.required {
+			return cmdArgs{}, ErrBadArgs
+		}
+		return a, nil
+	}
+
+	switch s.kind {
+	case argNone:
+		return cmdArgs{}, ErrBadArgs
+	case argChoice:
+		a.choice = strings.ToLower(a.raw)
+		if !slices.Contains(s.choices, a.choice) {
+			return cmdArgs{}, ErrBadArgs
+		}
+	case argFilter:
+		f, err := parseFilter(a.raw, now)
+		if err != nil {
+			return cmdArgs{}, err
+		}
+		a.filter = f
+	}
+
+	return a, nil
+}
+
+func (c *command) allowedIn(m Meta) bool {
+	if isPrivate(m) {
+		return c.scopes&scopePrivate != 0
+	}
+
+	return c.scopes&scopeGroup != 0
+}
+
+// lookupCmd finds the command by its name or alias, with or without leading "/".
+func (p *Processor) lookupCmd(name string) (*command, bool) {
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+
+	c, ok := p.handlers[strings.ToLower(name)]
+
+	return c, ok
+}
+
+func (p *Processor) middleHandler(ctx context.Context, name, arg string, m Meta) error {
+	c, ok := p.lookupCmd(name)
+	if !ok {
+		return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgUnknownCommand))
+	}
+
+	if !c.allowedIn(m) {
+		if isPrivate(m) {
+			return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgGroupOnly))
+		}
+		return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgPrivateOnly))
+	}
+
+	a, err := c.args.parse(arg, time.Now())
+	if errors.Is(err, ErrBadFilter) {
+		return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgIncorrectFilter))
+	}
+	if err != nil {
+		return p.sendUsage(ctx, m, c.name)
+	}
+
+	return c.handler(ctx, a, m)
+}
+
+func (p *Processor) sendUsage(ctx context.Context, m Meta, name string) error {
+	c, ok := p.lookupCmd(name)
+	if !ok {
+		return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgUnknownCommand))
+	}
+
+	return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgUsage, m.t(c.usage)))
+}
+
+// helpText lists commands available in the chat, it's generated from the registry.
+func (p *Processor) helpText(m Meta) string {
+	var sb strings.Builder
+
+	sb.WriteString(m.t(msgHelpIntro, p.botUsername) + "\n\n")
+	sb.WriteString(m.t(msgHelpCommands) + "\n")
+
+	for _, c := range p.commands {
+		if !c.allowedIn(m) {
+			continue
+		}
+		sb.WriteString("• " + m.t(c.usage) + " — " + m.t(c.description, c.descArgs...) + "\n")
+	}
+
+	sb.WriteString("\n" + m.t(msgHelpFooter))
+
+	return sb.String()
+}
+
+// commandHelp is the text of /help <command>.
+func (p *Processor) commandHelp(m Meta, c *command) string {
+	var sb strings.Builder
+
+	sb.WriteString(m.t(c.usage) + "\n" + m.t(c.description, c.descArgs...))
+
+	if len(c.aliases) > 0 {
+		sb.WriteString("\n\n" + m.t(msgHelpAliases, strings.Join(c.aliases, ", ")))
+	}
+
+	if c.details != "" {
+		sb.WriteString("\n\n" + m.t(c.details))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,310 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"narasla_bot/clients/telegram"
+	"narasla_bot/lib/e"
+	"narasla_bot/lib/i18n"
+	"narasla_bot/selector"
+	"narasla_bot/storage"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const timezoneFlow = "timezone"
+
+// windowPresets are delivery windows offered in /settings, hours [start, end).
+var windowPresets = [][2]int{
+	{7, 12},
+	{9, 18},
+	{12, 18},
+	{18, 24},
+	{storage.DefaultWindowStart, storage.DefaultWindowEnd},
+}
+
+// sendSettings shows settings of the list owner with buttons to change them.
+func (p *Processor) sendSettings(ctx context.Context, m Meta) (err error) {
+	defer func() { err = e.Wrap("Commands: can't send settings", err) }()
+
+	user, err := p.storage.GetUserInfo(ctx, m.OwnerID)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgUnknownUser))
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = p.tg.SendMessageWithKeyboard(ctx, m.Chat.ID, m.t(msgSettings), settingsKeyboard(m.Lang, user.Settings))
+
+	return err
+}
+
+// cbSettings handles "set:<action>[:<value>]" buttons and edits the settings message in place.
+func (p *Processor) cbSettings(ctx context.Context, data string, m Meta) (err error) {
+	defer func() { err = e.Wrap("Commands: can't change settings", err) }()
+
+	action, value, _ := strings.Cut(data, ":")
+
+	user, err := p.storage.GetUserInfo(ctx, m.OwnerID)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return p.tg.AnswerCallbackQuery(ctx, m.CallbackID, m.t(msgUnknownUser))
+	}
+	if err != nil {
+		return err
+	}
+
+	set := user.Settings
+	kb := settingsKeyboard(m.Lang, set)
+
+	if isGroupList(m) && action != "back" {
+		ok, err := p.requireAdmin(ctx, m)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	switch action {
+	case "autopush":
+		set.Autopush = !set.Autopush
+	case "archive":
+		set.Archive = !set.Archive
+	case "strategy":
+		set.Strategy = string(nextStrategy(set.Strategy))
+	case "lang":
+		set.Lang = string(nextLang(set.Lang))
+	case "window":
+		if value == "" {
+			kb = windowKeyboard(m.Lang)
+			break
+		}
+
+		start, end, ok := parseWindow(value)
+		if !ok {
+			return p.tg.AnswerCallbackQuery(ctx, m.CallbackID, "")
+		}
+		set.WindowStart, set.WindowEnd = start, end
+	case "tz":
+		if err := p.tg.AnswerCallbackQuery(ctx, m.CallbackID, ""); err != nil {
+			return err
+		}
+
+		return p.askTimezone(ctx, m, m.MessageID)
+	case "back":
+	default:
+		return p.tg.AnswerCallbackQuery(ctx, m.CallbackID, "")
+	}
+
+	if set != user.Settings {
+		if err := p.storage.UpdateSettings(ctx, m.OwnerID, set); err != nil {
+			return err
+		}
+
+		if lang, ok := i18n.Parse(set.Lang); ok && !isGroupList(m) {
+			m.Lang = lang
+		}
+		kb = settingsKeyboard(m.Lang, set)
+	}
+
+	if err := p.tg.AnswerCallbackQuery(ctx, m.CallbackID, ""); err != nil {
+		return err
+	}
+
+	return p.tg.EditMessageText(ctx, m.Chat.ID, m.MessageID, m.t(msgSettings), &kb)
+}
+
+// askTimezone waits for the timezone in the next message,
+// settingsMsgID is the settings message to update after the answer.
+func (p *Processor) askTimezone(ctx context.Context, m Meta, settingsMsgID int64) error {
+	if err := p.await(ctx, m, timezoneFlow, "", strconv.FormatInt(settingsMsgID, 10)); err != nil {
+		return err
+	}
+
+	return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgAskTimezone))
+}
+
+func (p *Processor) fTimezone(ctx context.Context, c *storage.Conversation, input string, m Meta) (err error) {
+	defer func() { err = e.Wrap("Commands: can't change timezone", err) }()
+
+	settingsMsgID, _ := strconv.ParseInt(c.Data, 10, 64)
+
+	tz, ok := parseTimezone(input)
+	if !ok {
+		if err := p.await(ctx, m, timezoneFlow, "", c.Data); err != nil {
+			return err
+		}
+
+		return p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgIncorrectTimezone))
+	}
+
+	user, err := p.storage.GetUserInfo(ctx, m.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	set := user.Settings
+	set.Timezone = tz
+
+	if err := p.storage.UpdateSettings(ctx, m.OwnerID, set); err != nil {
+		return err
+	}
+
+	if err := p.tg.SendMessage(ctx, m.Chat.ID, m.t(msgTimezoneChanged, tz)); err != nil {
+		return err
+	}
+
+	if settingsMsgID == 0 {
+		return nil
+	}
+
+	kb := settingsKeyboard(m.Lang, set)
+
+	return p.tg.EditMessageText(ctx, m.Chat.ID, settingsMsgID, m.t(msgSettings), &kb)
+}
+
+func settingsKeyboard(lang i18n.Lang, set storage.Settings) telegram.InlineKeyboardMarkup {
+	onOff := catalog.T(lang, msgOff)
+	if set.Autopush {
+		onOff = catalog.T(lang, msgOn)
+	}
+
+	afterDelivery := catalog.T(lang, msgAfterDeliveryDelete)
+	if set.Archive {
+		afterDelivery = catalog.T(lang, msgAfterDeliveryKeep)
+	}
+
+	setLang, _ := i18n.Parse(set.Lang)
+
+	button := func(key i18n.Key, value, action string) []telegram.InlineKeyboardButton {
+		return []telegram.InlineKeyboardButton{{
+			Text:         catalog.T(lang, key, value),
+			CallbackData: settingsCallback + ":" + action,
+		}}
+	}
+
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{
+			button(msgSettingAutopush, onOff, "autopush"),
+			button(msgSettingTimezone, set.Timezone, "tz"),
+			button(msgSettingWindow, formatWindow(set.WindowStart, set.WindowEnd), "window"),
+			button(msgSettingAfterDelivery, afterDelivery, "archive"),
+			button(msgSettingStrategy, catalog.T(lang, strategyNames[strategyOf(set)]), "strategy"),
+			button(msgSettingLang, catalog.T(setLang, msgLangName), "lang"),
+		},
+	}
+}
+
+func windowKeyboard(lang i18n.Lang) telegram.InlineKeyboardMarkup {
+	rows := make([][]telegram.InlineKeyboardButton, 0, len(windowPresets)+1)
+
+	for _, w := range windowPresets {
+		rows = append(rows, []telegram.InlineKeyboardButton{{
+			Text:         formatWindow(w[0], w[1]),
+			CallbackData: fmt.Sprintf("%s:window:%d-%d", settingsCallback, w[0], w[1]),
+		}})
+	}
+
+	rows = append(rows, []telegram.InlineKeyboardButton{{
+		Text:         catalog.T(lang, msgBackButton),
+		CallbackData: settingsCallback + ":back",
+	}})
+
+	return telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func formatWindow(start, end int) string {
+	return fmt.Sprintf("%d:00–%d:00", start, end)
+}
+
+// parseWindow accepts only the presets, so the data of a button can't be forged into anything else.
+func parseWindow(s string) (start, end int, ok bool) {
+	from, to, found := strings.Cut(s, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, false
+	}
+	end, err = strconv.Atoi(to)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if !slices.Contains(windowPresets, [2]int{start, end}) {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
+
+var strategyNames = map[selector.Strategy]i18n.Key{
+	selector.Random:     msgStrategyRandom,
+	selector.Oldest:     msgStrategyOldest,
+	selector.Newest:     msgStrategyNewest,
+	selector.Weighted:   msgStrategyWeighted,
+	selector.RoundRobin: msgStrategyRoundRobin,
+	selector.TagTurns:   msgStrategyTagTurns,
+}
+
+func strategyOf(set storage.Settings) selector.Strategy {
+	s := selector.Strategy(set.Strategy)
+	if _, ok := strategyNames[s]; !ok {
+		return selector.Random
+	}
+
+	return s
+}
+
+func nextStrategy(current string) selector.Strategy {
+	i := slices.Index(selector.Strategies, strategyOf(storage.Settings{Strategy: current}))
+
+	return selector.Strategies[(i+1)%len(selector.Strategies)]
+}
+
+func nextLang(current string) i18n.Lang {
+	lang, _ := i18n.Parse(current)
+
+	i := slices.Index(i18n.Langs, lang)
+
+	return i18n.Langs[(i+1)%len(i18n.Langs)]
+}
+
+// parseTimezone accepts IANA names like Europe/Moscow and UTC offsets in whole hours like +3 or UTC-5.
+func parseTimezone(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+
+	offset := strings.ToUpper(s)
+	offset = strings.TrimPrefix(offset, "UTC")
+	offset = strings.TrimPrefix(offset, "GMT")
+
+	if offset == "" {
+		return "UTC", true
+	}
+
+	if n, err := strconv.Atoi(offset); err == nil {
+		if n < -12 || n > 14 {
+			return "", false
+		}
+		if n == 0 {
+			return "UTC", true
+		}
+
+		// Etc/GMT zones have the sign inverted: Etc/GMT-3 is UTC+3.
+		return fmt.Sprintf("Etc/GMT%+d", -n), true
+	}
+
+	if strings.EqualFold(s, "local") {
+		return "", false
+	}
+
+	if _, err := time.LoadLocation(s); err != nil {
+		return "", false
+	}
+
+	return s, true
+}
@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"narasla_bot/lib/e"
+	"narasla_bot/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notNowDelay is how long the "Not now" button under a delivered page snoozes it.
+const notNowDelay = 24 * time.Hour
+
+const timeLayout = "2006-01-02 15:04"
+
+// snoozePage hides a page of /list for a while: /snooze <number> <duration>.
+func (p *Processor) snoozePage(ctx context.Context, m Meta, arg string) (err error) {
+	defer func() { err = e.Wrap("Commands: can't snooze page", err) }()
+
+	sendMsg := newMessageSender(ctx, m, p.tg)
+	sendMsgN := newPluralMessageSender(ctx, m, p.tg)
+
+	fields := strings.Fields(strings.ToLower(arg))
+	if len(fields) != 2 {
+		return p.sendUsage(ctx, m, SnoozeCmd)
+	}
+
+	num, err := strconv.Atoi(fields[0])
+	if err != nil || num <= 0 {
+		return p.sendUsage(ctx, m, SnoozeCmd)
+	}
+
+	delay, err := parseSnooze(fields[1])
+	if err != nil {
+		return p.sendUsage(ctx, m, SnoozeCmd)
+	}
+
+	list, err := p.storage.List(ctx, m.OwnerID, m.Username, storage.Filter{}, p.cfg.ListLimit, 0)
+	if err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		return sendMsg(msgNoSavedPages)
+	}
+
+	if num > len(list) {
+		return sendMsgN(msgOnlyItems, len(list))
+	}
+
+	page := list[num-1]
+	until := time.Now().Add(delay)
+
+	if err := p.storage.Snooze(ctx, m.OwnerID, page.ID, until); err != nil {
+		return err
+	}
+
+	set, err := p.ownerSettings(ctx, m.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	return sendMsg(msgSnoozed, until.In(set.Location()).Format(timeLayout), page.URL)
+}
+
+// parseSnooze parses snooze lengths like 30m, 12h, 3d or 2w. Unlike parseAge,
+// m means minutes here, a month is 4w.
+func parseSnooze(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, ErrBadAge
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, ErrBadAge
+	}
+
+	day := 24 * time.Hour
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = day
+	case 'w':
+		unit = 7 * day
+	default:
+		return 0, ErrBadAge
+	}
+
+	return time.Duration(n) * unit, nil
+}
+
+// notNow snoozes a delivered page for notNowDelay and returns text for the user.
+// Autopush and /rnd have removed the page already, so it's restored first.
+func (p *Processor) notNow(ctx context.Context, m Meta, pageID int64) (text string, err error) {
+	defer func() { err = e.Wrap("Commands: can't snooze delivered page", err) }()
+
+	_, err = p.storage.Undo(ctx, m.OwnerID, pageID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrAlreadyExists) {
+		return "", err
+	}
+
+	until := time.Now().Add(notNowDelay)
+
+	err = p.storage.Snooze(ctx, m.OwnerID, pageID, until)
+	if errors.Is(err, storage.ErrNotFound) {
+		return m.t(msgPageGone), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	list, err := p.storage.List(ctx, m.OwnerID, m.Username, storage.Filter{IDs: []int64{pageID}}, 1, 0)
+	if err != nil {
+		return "", err
+	}
+	if len(list) == 0 {
+		return m.t(msgPageGone), nil
+	}
+
+	set, err := p.ownerSettings(ctx, m.OwnerID)
+	if err != nil {
+		return "", err
+	}
+
+	return m.t(msgSnoozed, until.In(set.Location()).Format(timeLayout), list[0].URL), nil
+}
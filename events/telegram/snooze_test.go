@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseSnooze pins m to minutes: /snooze 1 30m must not hide a page for 30 months.
+func TestParseSnooze(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"30m", 30 * time.Minute},
+		{"12h", 12 * time.Hour},
+		{"3d", 72 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSnooze(tt.in)
+		if err != nil {
+			t.Fatalf("parseSnooze(%q): %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("parseSnooze(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "m", "0m", "-1h", "1y", "1mo", "2x"} {
+		if _, err := parseSnooze(in); err == nil {
+			t.Errorf("parseSnooze(%q) has no error", in)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"narasla_bot/clients/telegram"
 	"narasla_bot/events"
 	"narasla_bot/lib/e"
+	"narasla_bot/lib/i18n"
+	"time"
 
 	"narasla_bot/storage"
 )
@@ -20,30 +22,73 @@ type Processor struct {
 	offset      int
 	storage     storage.Storage // interface
 	botUsername string
-	handlers    map[string]handler
+	commands    []command
+	handlers    map[string]*command // by name and alias
+	callbacks   map[string]callbackHandler
+	flows       map[string]flowHandler
+	pending     *pendingDeletes
+	cfg         Config
+}
+
+type Config struct {
+	ListLimit  int           // pages shown by /list and the pickers of /del, /pin, /snooze and /read
+	CmdTimeout time.Duration // time to handle one command or button press
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ListLimit:  20,
+		CmdTimeout: 10 * time.Second,
+	}
 }
 
 // now we implement Meta interface exclusively for telegram
 type Meta struct {
-	Chat     Chat
-	UserID   int64
-	Username string
+	Chat      Chat
+	UserID    int64
+	OwnerID   int64 // owner of the list the message works with, set by resolveOwner
+	Username  string
+	MessageID int64
+	ReplyToID int64 // id of the message this one replies to, 0 if none
+
+	CallbackID string // set only for callback events, MessageID is the message with the button
+
+	LangCode string    // language_code of the Telegram client
+	Lang     i18n.Lang // language to answer in, set by resolveLang
+}
+
+// t translates the message into the language of the user.
+func (m Meta) t(key i18n.Key, args ...any) string {
+	return catalog.T(m.Lang, key, args...)
+}
+
+// n is t with plural form chosen by count.
+func (m Meta) n(key i18n.Key, count int, args ...any) string {
+	return catalog.N(m.Lang, key, count, args...)
 }
 
 type Chat struct {
-	ID   int64
-	Type string // "private", "group", "supergroup", or "channel"
+	ID    int64
+	Type  string // "private", "group", "supergroup", or "channel"
+	Title string
 }
 
-type handler func(ctx context.Context, arg string, m Meta) error
+type handler func(ctx context.Context, a cmdArgs, m Meta) error
+
+// callbackHandler gets callback data without the "<prefix>:" part.
+type callbackHandler func(ctx context.Context, data string, m Meta) error
 
-func New(tg *telegram.Client, st storage.Storage, botUsername string) *Processor {
+func New(tg *telegram.Client, st storage.Storage, botUsername string, cfg Config) *Processor {
 	p := &Processor{
 		tg:          tg,
 		storage:     st,
 		botUsername: botUsername,
+		pending:     newPendingDeletes(),
+		cfg:         cfg,
 	}
 	p.initHandlers()
+	p.initCallbacks()
+	p.initFlows()
 	return p
 }
 
@@ -84,6 +129,8 @@ func (p *Processor) Process(ctx context.Context, event events.Event) error {
 	switch event.Type {
 	case events.Message:
 		return p.processMessage(ctx, event)
+	case events.Callback:
+		return p.processCallback(ctx, event)
 	case events.Unknown:
 		return nil
 	default:
@@ -104,6 +151,19 @@ func (p *Processor) processMessage(ctx context.Context, event events.Event) erro
 	return nil
 }
 
+func (p *Processor) processCallback(ctx context.Context, event events.Event) error {
+	meta, err := meta(event)
+	if err != nil {
+		return e.Wrap("Events: processCallback failed to process callback", err)
+	}
+
+	if err := p.doCallback(ctx, event.Text, meta); err != nil {
+		return e.Wrap("Events: processCallback failed to process callback", err)
+	}
+
+	return nil
+}
+
 func meta(event events.Event) (Meta, error) {
 	res, ok := event.Meta.(Meta) // this call is type assertion
 	if !ok {
@@ -121,36 +181,66 @@ func event(upd telegram.Update) events.Event {
 		Text: fetchText(upd),
 	}
 
-	if updType == events.Message {
+	switch updType {
+	case events.Message:
 		res.Meta = Meta{
-			Chat:     getChatData(upd),
-			UserID:   upd.Message.From.ID,
-			Username: upd.Message.From.Username,
+			Chat:      getChatData(upd.Message),
+			UserID:    upd.Message.From.ID,
+			Username:  upd.Message.From.Username,
+			MessageID: upd.Message.MessageID,
+			ReplyToID: fetchReplyToID(upd),
+			LangCode:  upd.Message.From.LanguageCode,
+		}
+	case events.Callback:
+		q := upd.CallbackQuery
+		res.Meta = Meta{
+			Chat:       getChatData(q.Message),
+			UserID:     q.From.ID,
+			Username:   q.From.Username,
+			MessageID:  q.Message.MessageID,
+			CallbackID: q.ID,
+			LangCode:   q.From.LanguageCode,
 		}
 	}
 
 	return res
 }
 
-func getChatData(upd telegram.Update) Chat {
+func getChatData(msg *telegram.IncomingMessage) Chat {
 	return Chat{
-		ID:   upd.Message.Chat.ID,
-		Type: upd.Message.Chat.Type,
+		ID:    msg.Chat.ID,
+		Type:  msg.Chat.Type,
+		Title: msg.Chat.Title,
 	}
 }
 
 func fetchType(upd telegram.Update) events.Type {
-	if upd.Message == nil {
+	switch {
+	case upd.Message != nil:
+		return events.Message
+	// without the message there is nothing to answer to.
+	case upd.CallbackQuery != nil && upd.CallbackQuery.Message != nil:
+		return events.Callback
+	default:
 		return events.Unknown
 	}
-
-	return events.Message
 }
 
 func fetchText(upd telegram.Update) string {
-	if upd.Message == nil {
+	switch {
+	case upd.Message != nil:
+		return upd.Message.Text
+	case upd.CallbackQuery != nil:
+		return upd.CallbackQuery.Data
+	default:
 		return ""
 	}
+}
+
+func fetchReplyToID(upd telegram.Update) int64 {
+	if upd.Message == nil || upd.Message.ReplyToMessage == nil {
+		return 0
+	}
 
-	return upd.Message.Text
+	return upd.Message.ReplyToMessage.MessageID
 }
@@ -17,6 +17,7 @@ type Type int
 const (
 	Unknown Type = iota
 	Message
+	Callback
 )
 
 type Event struct {
@@ -0,0 +1,109 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Lang string
+
+const (
+	EN Lang = "en"
+	RU Lang = "ru"
+
+	Default = EN
+)
+
+// Langs are supported languages in the order they are offered to users.
+var Langs = []Lang{EN, RU}
+
+// Key identifies a message in a Catalog.
+type Key string
+
+// Message is one text, or plural forms of it for N:
+// en - one, other; ru - one, few, many.
+type Message []string
+
+// Catalog keeps messages of every language, missing ones fall back to Default.
+type Catalog map[Lang]map[Key]Message
+
+// Parse turns Telegram language_code ("ru", "en-US", ...) into a supported language.
+func Parse(code string) (Lang, bool) {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i != -1 {
+		code = code[:i]
+	}
+
+	for _, l := range Langs {
+		if string(l) == code {
+			return l, true
+		}
+	}
+
+	return Default, false
+}
+
+// T returns the message formatted with args.
+func (c Catalog) T(lang Lang, key Key, args ...any) string {
+	msg := c.lookup(lang, key)
+	if len(msg) == 0 {
+		return string(key)
+	}
+
+	return format(msg[0], args)
+}
+
+// N returns the plural form of the message for n formatted with args.
+func (c Catalog) N(lang Lang, key Key, n int, args ...any) string {
+	msg := c.lookup(lang, key)
+	if len(msg) == 0 {
+		return string(key)
+	}
+
+	form := pluralForm(lang, n)
+	if form >= len(msg) {
+		form = len(msg) - 1
+	}
+
+	return format(msg[form], args)
+}
+
+func (c Catalog) lookup(lang Lang, key Key) Message {
+	if msg, ok := c[lang][key]; ok {
+		return msg
+	}
+
+	return c[Default][key]
+}
+
+func format(text string, args []any) string {
+	if len(args) == 0 {
+		return text
+	}
+
+	return fmt.Sprintf(text, args...)
+}
+
+// pluralForm returns index of the plural form of n for the language.
+func pluralForm(lang Lang, n int) int {
+	if n < 0 {
+		n = -n
+	}
+
+	switch lang {
+	case RU:
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	default:
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}
+}
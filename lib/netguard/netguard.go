@@ -0,0 +1,113 @@
+// Package netguard makes HTTP clients for links users send. They connect only to public
+// addresses, so a saved link can't make the bot fetch its own network: localhost,
+// private ranges or a cloud metadata service like 169.254.169.254.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrNotPublic is returned for a connection to an address that isn't public.
+var ErrNotPublic = errors.New("netguard: address isn't public")
+
+// maxRedirects is the limit of http.Client, the default client stops at 10 too.
+const maxRedirects = 10
+
+// notPublic are ranges the netip methods don't cover.
+var notPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relay anycast
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, with the broadcast address
+
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, it may wrap a private IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("100::/64"),       // discard-only
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+	netip.MustParsePrefix("2002::/16"),      // 6to4, it may wrap a private IPv4
+	netip.MustParsePrefix("fec0::/10"),      // deprecated site-local
+}
+
+// IsPublic reports whether the address is a public unicast one: not loopback, private
+// (RFC 1918, fc00::/7), link-local with the metadata services, multicast or reserved.
+func IsPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+
+	if !ip.IsValid() || ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() {
+		return false
+	}
+
+	for _, p := range notPublic {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Client returns a client that connects only to public addresses. The address is
+// checked when the connection is made, after DNS, so a name resolving to a private
+// address is refused too, and so is every redirect. Proxies from the environment
+// aren't used: the check would see the proxy, not the site.
+func Client(timeout time.Duration) *http.Client {
+	return newClient(timeout, IsPublic)
+}
+
+func newClient(timeout time.Duration, allow func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return checkAddress(address, allow)
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to %s: %w", req.URL.Scheme, ErrNotPublic)
+			}
+			// a name is checked by the dialer once it's resolved.
+			if ip, err := netip.ParseAddr(req.URL.Hostname()); err == nil && !allow(ip) {
+				return fmt.Errorf("redirect to %s: %w", ip, ErrNotPublic)
+			}
+			return nil
+		},
+	}
+}
+
+// checkAddress checks the resolved "ip:port" the dialer connects to.
+func checkAddress(address string, allow func(netip.Addr) bool) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%s: %w", address, ErrNotPublic)
+	}
+	if !allow(ap.Addr()) {
+		return fmt.Errorf("%s: %w", ap.Addr(), ErrNotPublic)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"1.1.1.1", true},
+		{"93.184.215.14", true},
+		{"2606:4700:4700::1111", true},
+
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.1.1.1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublic(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("IsPublic(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestClientRefusesLocal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("internal"))
+	}))
+	defer srv.Close()
+
+	_, err := Client(time.Second).Get(srv.URL)
+	if !errors.Is(err, ErrNotPublic) {
+		t.Fatalf("a request to %s: %v, want ErrNotPublic", srv.URL, err)
+	}
+
+	// a name is checked after it's resolved.
+	_, err = Client(time.Second).Get(strings.Replace(srv.URL, "127.0.0.1", "localhost", 1))
+	if !errors.Is(err, ErrNotPublic) {
+		t.Fatalf("a request to localhost: %v, want ErrNotPublic", err)
+	}
+}
+
+func TestClientChecksRedirects(t *testing.T) {
+	inner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("internal"))
+	}))
+	defer inner.Close()
+
+	outer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	}))
+	defer outer.Close()
+
+	// the outer server plays a public site, the inner one the bot's network.
+	outerAddr := netip.MustParseAddrPort(strings.TrimPrefix(outer.URL, "http://"))
+	client := newClient(time.Second, func(ip netip.Addr) bool { return ip == outerAddr.Addr() })
+
+	for _, to := range []string{
+		strings.Replace(inner.URL, "127.0.0.1", "127.0.0.2", 1),
+		"http://169.254.169.254/latest/meta-data/",
+		"file:///etc/passwd",
+	} {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, outer.URL+"/?to="+to, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		if !errors.Is(err, ErrNotPublic) {
+			t.Errorf("redirect to %s: %v, want ErrNotPublic", to, err)
+		}
+	}
+}
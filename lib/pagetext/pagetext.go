@@ -0,0 +1,32 @@
+// Package pagetext formats a delivered page, so /rnd, autopush and reminders look the same.
+package pagetext
+
+import (
+	"narasla_bot/lib/i18n"
+	"narasla_bot/storage"
+)
+
+const msgNoteLabel i18n.Key = "note_label"
+
+var catalog = i18n.Catalog{
+	i18n.EN: {
+		msgNoteLabel: {"Note: %s"},
+	},
+	i18n.RU: {
+		msgNoteLabel: {"Заметка: %s"},
+	},
+}
+
+// Text is how a page looks when it's delivered: the link and the note under it.
+func Text(lang i18n.Lang, page *storage.Page) string {
+	if page.Note == "" {
+		return page.URL
+	}
+
+	return page.URL + "\n\n" + Note(lang, page.Note)
+}
+
+// Note is the labeled note, /list shows it under the link too.
+func Note(lang i18n.Lang, note string) string {
+	return catalog.T(lang, msgNoteLabel, note)
+}
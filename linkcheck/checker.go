@@ -0,0 +1,252 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"narasla_bot/lib/netguard"
+	"narasla_bot/storage"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	requestTimeout = 15 * time.Second
+	userAgent      = "Mozilla/5.0 (compatible; narasla_bot; +https://core.telegram.org/bots)"
+)
+
+type Config struct {
+	Tick         time.Duration // how often to look for pages to check
+	RecheckAfter time.Duration // a page is checked again after that
+	BatchSize    int           // pages checked in one tick
+	Workers      int           // hosts checked at the same time
+	HostDelay    time.Duration // pause between requests to the same host
+	ReportEvery  time.Duration // how often an owner is told about broken pages
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Tick:         time.Hour,
+		RecheckAfter: 30 * 24 * time.Hour,
+		BatchSize:    200,
+		Workers:      8,
+		HostDelay:    2 * time.Second,
+		ReportEvery:  7 * 24 * time.Hour,
+	}
+}
+
+// Checker finds saved pages with dead links and reports them to their owners weekly.
+type Checker struct {
+	st     CheckerStorage
+	tg     Sender
+	client *http.Client
+	cfg    Config
+}
+
+// New makes a checker. Without a client it uses the one of the enricher that requests only
+// public addresses: the status goes back to the user and mustn't tell about the bot's network.
+func New(st CheckerStorage, tg Sender, client *http.Client, cfg Config) *Checker {
+	if client == nil {
+		client = netguard.Client(requestTimeout)
+	}
+
+	return &Checker{
+		st:     st,
+		tg:     tg,
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+func (c *Checker) Run(ctx context.Context) error {
+	t := time.NewTicker(c.cfg.Tick)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			if err := c.step(ctx); err != nil {
+				log.Printf("link checker step error: %v", err)
+			}
+		}
+	}
+}
+
+func (c *Checker) step(ctx context.Context) error {
+	now := time.Now()
+
+	if _, _, err := c.CheckDue(ctx, now); err != nil {
+		return err
+	}
+
+	return c.report(ctx, now)
+}
+
+// CheckDue checks one batch of pages that weren't checked for RecheckAfter and saves the results,
+// without reporting. It returns how many pages got a result and how many of them are broken,
+// 0 checked means there is nothing left to check now.
+func (c *Checker) CheckDue(ctx context.Context, now time.Time) (checked, broken int, err error) {
+	pages, err := c.st.ListUnchecked(ctx, now.Add(-c.cfg.RecheckAfter), c.cfg.BatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// stops the workers if SetLinkStatus fails and we return early.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for res := range c.checkAll(ctx, pages) {
+		if err := c.st.SetLinkStatus(ctx, res.pageID, res.status); err != nil {
+			return checked, broken, err
+		}
+
+		checked++
+		if res.status.Broken {
+			broken++
+		}
+	}
+
+	return checked, broken, ctx.Err()
+}
+
+type result struct {
+	pageID int64
+	status storage.LinkStatus
+}
+
+// checkAll checks pages of different hosts in parallel and pages of one host one by one
+// with cfg.HostDelay between them, so no site gets a burst of requests from the bot.
+// Results come from a single channel, it's closed when all pages are checked.
+func (c *Checker) checkAll(ctx context.Context, pages []storage.Page) <-chan result {
+	byHost := make(map[string][]storage.Page)
+	for _, p := range pages {
+		host := storage.DomainOf(p.URL)
+		byHost[host] = append(byHost[host], p)
+	}
+
+	hosts := make(chan []storage.Page)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for range max(c.cfg.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for hostPages := range hosts {
+				c.checkHost(ctx, hostPages, results)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(results)
+		defer wg.Wait()
+		defer close(hosts)
+
+		for _, hostPages := range byHost {
+			select {
+			case hosts <- hostPages:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+func (c *Checker) checkHost(ctx context.Context, pages []storage.Page, results chan<- result) {
+	for i, p := range pages {
+		if i > 0 {
+			select {
+			case <-time.After(c.cfg.HostDelay):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		status, ok := c.Check(ctx, p.URL)
+		if !ok {
+			continue
+		}
+
+		select {
+		case results <- result{pageID: p.ID, status: status}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check requests the page with HEAD, or with GET if the site doesn't support HEAD.
+// ok is false if the result tells nothing about the page, e.g. the bot is offline or stopping.
+func (c *Checker) Check(ctx context.Context, pageURL string) (status storage.LinkStatus, ok bool) {
+	code, err := c.request(ctx, http.MethodHead, pageURL)
+	if err == nil && headUnsupported(code) {
+		code, err = c.request(ctx, http.MethodGet, pageURL)
+	}
+
+	if ctx.Err() != nil {
+		return storage.LinkStatus{}, false
+	}
+
+	status = storage.LinkStatus{Code: code, CheckedAt: time.Now()}
+
+	switch {
+	case err == nil:
+		status.Broken = isGone(code)
+	case isNoHost(err):
+		status.Broken = true
+	}
+
+	return status, true
+}
+
+func (c *Checker) request(ctx context.Context, method, pageURL string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, pageURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// a little of the body is read, so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
+
+func headUnsupported(code int) bool {
+	return code == http.StatusMethodNotAllowed ||
+		code == http.StatusNotImplemented ||
+		code == http.StatusForbidden ||
+		code == http.StatusNotFound // some sites answer 404 to HEAD only
+}
+
+// isGone is true only for statuses which mean the page won't come back.
+// 5xx, 403 and 429 are often temporary or an anti-bot wall, such pages aren't reported.
+func isGone(code int) bool {
+	return code == http.StatusNotFound ||
+		code == http.StatusGone ||
+		code == http.StatusUnavailableForLegalReasons
+}
+
+func isNoHost(err error) bool {
+	var dnsErr *net.DNSError
+
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,112 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"narasla_bot/storage"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/nohead":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/busy":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(nil, nil, srv.Client(), DefaultConfig())
+
+	tests := []struct {
+		path   string
+		code   int
+		broken bool
+	}{
+		{"/", http.StatusOK, false},
+		{"/gone", http.StatusGone, true},
+		{"/nohead", http.StatusOK, false},
+		{"/busy", http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		status, ok := c.Check(context.Background(), srv.URL+tt.path)
+		if !ok || status.Code != tt.code || status.Broken != tt.broken {
+			t.Errorf("%s: %+v ok=%v, want code %d broken %v", tt.path, status, ok, tt.code, tt.broken)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// failingStorage gives pages to check and fails to save their status.
+type failingStorage struct {
+	CheckerStorage
+	pages []storage.Page
+}
+
+func (s *failingStorage) ListUnchecked(context.Context, time.Time, int) ([]storage.Page, error) {
+	return s.pages, nil
+}
+
+func (s *failingStorage) SetLinkStatus(context.Context, int64, storage.LinkStatus) error {
+	return errors.New("disk is full")
+}
+
+// TestCheckDueStopsWorkers checks that workers don't stay blocked when CheckDue returns early.
+func TestCheckDueStopsWorkers(t *testing.T) {
+	st := &failingStorage{}
+	for i := range 20 {
+		st.pages = append(st.pages, storage.Page{ID: int64(i + 1), URL: fmt.Sprintf("https://site%d.example/", i%5)})
+	}
+
+	// no network, so the only goroutines left would be the checker's.
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+	})}
+
+	cfg := DefaultConfig()
+	cfg.HostDelay = 0
+	c := New(st, nil, client, cfg)
+
+	before := runtime.NumGoroutine()
+
+	if _, _, err := c.CheckDue(context.Background(), time.Now()); err == nil {
+		t.Fatal("CheckDue: no error from SetLinkStatus")
+	}
+
+	for deadline := time.Now().Add(2 * time.Second); runtime.NumGoroutine() > before; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines left running, %d before CheckDue", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestCheckLocal checks that the default client doesn't request the bot's own network.
+func TestCheckLocal(t *testing.T) {
+	requested := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	status, _ := New(nil, nil, nil, DefaultConfig()).Check(context.Background(), srv.URL+"/admin")
+	if requested || status.Code != 0 || status.Broken {
+		t.Errorf("a local page was requested: %+v", status)
+	}
+}
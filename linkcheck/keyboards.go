@@ -0,0 +1,25 @@
+package linkcheck
+
+import (
+	"narasla_bot/clients/telegram"
+	"narasla_bot/lib/i18n"
+)
+
+// brokenCallback is handled by events/telegram: "broken:del" removes all broken pages
+// of the owner, "broken:keep" just hides the buttons.
+const brokenCallback = "broken"
+
+func brokenKeyboard(lang i18n.Lang) telegram.InlineKeyboardMarkup {
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{
+				Text:         catalog.T(lang, msgRemoveBrokenButton),
+				CallbackData: brokenCallback + ":del",
+			},
+			{
+				Text:         catalog.T(lang, msgKeepBrokenButton),
+				CallbackData: brokenCallback + ":keep",
+			},
+		}},
+	}
+}
@@ -0,0 +1,32 @@
+package linkcheck
+
+import "narasla_bot/lib/i18n"
+
+const (
+	msgBrokenReport       i18n.Key = "broken_report"
+	msgBrokenMore         i18n.Key = "broken_more"
+	msgRemoveBrokenButton i18n.Key = "remove_broken_button"
+	msgKeepBrokenButton   i18n.Key = "keep_broken_button"
+)
+
+var catalog = i18n.Catalog{
+	i18n.EN: {
+		msgBrokenReport: {
+			"%d saved page doesn't open anymore:",
+			"%d saved pages don't open anymore:",
+		},
+		msgBrokenMore:         {"…and %d more"},
+		msgRemoveBrokenButton: {"Remove them"},
+		msgKeepBrokenButton:   {"Keep"},
+	},
+	i18n.RU: {
+		msgBrokenReport: {
+			"%d сохранённая страница больше не открывается:",
+			"%d сохранённые страницы больше не открываются:",
+			"%d сохранённых страниц больше не открываются:",
+		},
+		msgBrokenMore:         {"…и ещё %d"},
+		msgRemoveBrokenButton: {"Удалить их"},
+		msgKeepBrokenButton:   {"Оставить"},
+	},
+}
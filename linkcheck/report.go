@@ -0,0 +1,77 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"narasla_bot/lib/i18n"
+	"narasla_bot/storage"
+	"strings"
+	"time"
+)
+
+const (
+	// reportBatchSize limits reports sent in one tick, the rest go on the next ones.
+	reportBatchSize = 100
+
+	// reportListSize is how many broken links a report shows, the button removes all of them.
+	reportListSize = 10
+)
+
+// report tells owners about their broken pages, once in cfg.ReportEvery.
+func (c *Checker) report(ctx context.Context, now time.Time) error {
+	owners, err := c.st.ListBrokenOwners(ctx, now.Add(-c.cfg.ReportEvery), reportBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, ownerID := range owners {
+		if err := c.reportOwner(ctx, ownerID); err != nil {
+			// the chat may be gone, the owner is asked again after cfg.ReportEvery.
+			log.Printf("link checker: can't report broken pages owner=%d: %v", ownerID, err)
+		}
+
+		if err := c.st.SetBrokenReportAt(ctx, ownerID, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Checker) reportOwner(ctx context.Context, ownerID int64) error {
+	u, err := c.st.GetUserInfo(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	lang, _ := i18n.Parse(u.Lang)
+
+	f := storage.Filter{Broken: true}
+
+	count, err := c.st.Count(ctx, ownerID, f)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	pages, err := c.st.List(ctx, ownerID, u.Username, f, reportListSize, 0)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(catalog.N(lang, msgBrokenReport, count, count) + "\n\n")
+	for i, p := range pages {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, p.URL))
+	}
+	if count > len(pages) {
+		sb.WriteString(catalog.T(lang, msgBrokenMore, count-len(pages)) + "\n")
+	}
+
+	_, err = c.tg.SendMessageWithKeyboard(ctx, u.ChatID, sb.String(), brokenKeyboard(lang))
+
+	return err
+}
@@ -0,0 +1,22 @@
+package linkcheck
+
+import (
+	"context"
+	"narasla_bot/clients/telegram"
+	"narasla_bot/storage"
+	"time"
+)
+
+type CheckerStorage interface {
+	ListUnchecked(ctx context.Context, checkedBefore time.Time, limit int) ([]storage.Page, error)
+	SetLinkStatus(ctx context.Context, pageID int64, s storage.LinkStatus) error
+	ListBrokenOwners(ctx context.Context, reportedBefore time.Time, limit int) ([]int64, error)
+	SetBrokenReportAt(ctx context.Context, ownerID int64, at time.Time) error
+	GetUserInfo(ctx context.Context, ownerID int64) (*storage.User, error)
+	List(ctx context.Context, ownerID int64, username string, f storage.Filter, limit, offset int) ([]storage.Page, error)
+	Count(ctx context.Context, ownerID int64, f storage.Filter) (int, error)
+}
+
+type Sender interface {
+	SendMessageWithKeyboard(ctx context.Context, chatID int64, text string, kb telegram.InlineKeyboardMarkup) (int64, error)
+}
@@ -3,78 +3,184 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // the release image has no zoneinfo, timezones come from /settings
 
+	"narasla_bot/backup"
 	tgClient "narasla_bot/clients/telegram"
+	"narasla_bot/config"
 	"narasla_bot/consumers/event_consumer"
+	"narasla_bot/enricher"
 	"narasla_bot/events/telegram"
+	"narasla_bot/linkcheck"
+	"narasla_bot/postgres"
 	"narasla_bot/scheduler"
 	"narasla_bot/sqlite"
-
-	"github.com/joho/godotenv"
-)
-
-const (
-	tgBotHost = "api.telegram.org"
-	batchSize = 100
+	"narasla_bot/storage"
+	"narasla_bot/storage/files"
+	"narasla_bot/storage/memory"
 )
 
 func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
-	if os.Getenv("TG_BOT_TOKEN") == "" &&
-		os.Getenv("BOT_USERNAME") == "" &&
-		os.Getenv("STORAGE_PATH") == "" {
-		if err := godotenv.Load(); err != nil {
-			log.Printf("Error loading .env file: %v", err)
+
+	admin := len(os.Args) > 1
+
+	cfg, err := config.Load(!admin)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if admin {
+		if err := runAdmin(ctx, cfg, os.Args[1:]); err != nil {
+			log.Fatal(err)
 		}
+		return
 	}
 
-	tgToken := mustEnv("TG_BOT_TOKEN")
-	storagePath := mustEnv("STORAGE_PATH")
-	botUsername := mustEnv("BOT_USERNAME")
+	log.Printf("config:\n%s", cfg)
+
+	if storageDriver(cfg.StorageDriver, cfg.StoragePath) == "sqlite" {
+		// admin restore refuses to replace the database while it's locked.
+		unlock, err := sqlite.Lock(cfg.StoragePath)
+		if err != nil {
+			log.Fatalf("can't open the storage: %v", err)
+		}
+		defer func() { _ = unlock() }()
+	}
 
-	s, err := sqlite.New(storagePath)
+	s, closeStorage, err := openStorage(ctx, cfg.StorageDriver, cfg.StoragePath)
 	if err != nil {
-		log.Fatalf("can't connect to the storage: %v", err)
+		log.Fatalf("can't open the storage: %v", err)
 	}
+	defer func() {
+		if err := closeStorage(); err != nil {
+			log.Printf("can't close the storage: %v", err)
+		}
+	}()
+
+	tgCl := tgClient.New(cfg.TGHost, cfg.Token)
 
-	if err := s.Init(ctx); err != nil {
-		log.Fatalf("can't init sql storage: %v", err)
+	me, err := tgCl.GetMe(ctx)
+	if err != nil {
+		log.Fatalf("can't get bot info, check TG_BOT_TOKEN: %v", err)
 	}
 
-	tgCl := tgClient.New(tgBotHost, tgToken)
+	botUsername := me.Username
+	if cfg.BotUsername != "" && !strings.EqualFold(cfg.BotUsername, botUsername) {
+		log.Printf("BOT_USERNAME=%s doesn't match the token, using @%s", cfg.BotUsername, botUsername)
+	}
 
 	eventsProcessor := telegram.New(
 		tgCl,
 		s,
 		botUsername,
+		cfg.Commands,
 	)
 
-	sch := scheduler.New(s, tgCl, 10*time.Minute)
+	if err := eventsProcessor.PublishCommands(ctx); err != nil {
+		log.Printf("can't publish command menu: %v", err)
+	}
+
+	sch := scheduler.New(s, tgCl, cfg.SchedulerTick)
 	go func() {
 		if err := sch.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
 			log.Printf("scheduler stopped: %v", err)
 		}
 	}()
 
+	enr := enricher.New(s, nil, time.Minute, cfg.Snapshots)
+	go func() {
+		if err := enr.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("enricher stopped: %v", err)
+		}
+	}()
+
+	if cfg.LinkCheckOn {
+		checker := linkcheck.New(s, tgCl, nil, cfg.LinkCheck)
+		go func() {
+			if err := checker.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("link checker stopped: %v", err)
+			}
+		}()
+	}
+
+	if cfg.Backup.Dir != "" {
+		if db, ok := s.(backup.Database); ok {
+			b := backup.New(db, cfg.Backup)
+			go func() {
+				if err := b.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					log.Printf("backups stopped: %v", err)
+				}
+			}()
+		} else {
+			log.Printf("BACKUP_DIR is set, but the %q storage has no backups", cfg.StorageDriver)
+		}
+	}
+
 	log.Print("Server is running")
 
-	consumer := event_consumer.New(eventsProcessor, eventsProcessor, batchSize)
+	consumer := event_consumer.New(eventsProcessor, eventsProcessor, cfg.Consumer)
 	if err := consumer.Start(ctx); err != nil && !errors.Is(err, context.Canceled) {
 		log.Fatalf("servise is stopped: %v", err)
 	}
 }
 
-func mustEnv(key string) string {
-	v := os.Getenv(key)
-	if v == "" {
-		log.Fatalf("no %s key found in .env file", key)
+// openStorage opens the backend chosen by STORAGE_DRIVER: "sqlite" (default) takes
+// a database file, "postgres" a DSN, "files" a directory, "memory" ignores the path
+// and forgets everything on exit. Without a driver a postgres:// path means postgres.
+func openStorage(ctx context.Context, driver, path string) (storage.Storage, func() error, error) {
+	switch storageDriver(driver, path) {
+	case "sqlite":
+		s, err := sqlite.New(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.Init(ctx); err != nil {
+			_ = s.Close()
+			return nil, nil, err
+		}
+		return s, s.Close, nil
+	case "postgres":
+		s, err := postgres.New(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.Init(ctx); err != nil {
+			_ = s.Close()
+			return nil, nil, err
+		}
+		return s, s.Close, nil
+	case "files":
+		s, err := files.New(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, s.Close, nil
+	case "memory":
+		return memory.New(), func() error { return nil }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown STORAGE_DRIVER %q, use sqlite, postgres, files or memory", driver)
 	}
+}
 
-	return v
+// storageDriver returns the lower case driver name, see openStorage.
+func storageDriver(driver, path string) string {
+	driver = strings.ToLower(driver)
+
+	switch {
+	case driver != "":
+		return driver
+	case strings.HasPrefix(path, "postgres://") || strings.HasPrefix(path, "postgresql://"):
+		return "postgres"
+	default:
+		return "sqlite"
+	}
 }
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationLock is the advisory lock key that keeps instances starting together from migrating twice.
+const migrationLock = 7_322_510
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// migrate applies every migration not in schema_migrations yet.
+// Each one runs in its own transaction together with the version row.
+func (s *Storage) migrate(ctx context.Context) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);`); err != nil {
+		return fmt.Errorf("can't create schema_migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		if err := s.applyMigration(ctx, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) applyMigration(ctx context.Context, m migration) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin migration %s: %w", m.name, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// the lock is released with the transaction, the next instance sees the version row.
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1);", migrationLock); err != nil {
+		return fmt.Errorf("can't lock migrations: %w", err)
+	}
+
+	var applied bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1);", m.version).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("can't get schema version: %w", err)
+	}
+	if applied {
+		return tx.Commit()
+	}
+
+	if _, err := tx.ExecContext(ctx, m.query); err != nil {
+		return fmt.Errorf("can't apply migration %s: %w", m.name, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1);", m.version); err != nil {
+		return fmt.Errorf("can't set schema version %d: %w", m.version, err)
+	}
+
+	return tx.Commit()
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("can't read migrations: %w", err)
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("bad migration name: %s", name)
+		}
+
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("bad migration version %s: %w", name, err)
+		}
+
+		query, err := migrationsFS.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, fmt.Errorf("can't read migration %s: %w", name, err)
+		}
+
+		migrations = append(migrations, migration{
+			version: version,
+			name:    name,
+			query:   string(query),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,98 @@
+CREATE TABLE IF NOT EXISTS pages (
+    id BIGSERIAL PRIMARY KEY,
+    owner_id BIGINT NOT NULL,
+    chat_id BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    user_name TEXT NOT NULL DEFAULT '',
+    domain TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    read_at BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    snoozed_until BIGINT,
+    pinned BOOLEAN NOT NULL DEFAULT false,
+    content_kind TEXT NOT NULL DEFAULT '',
+    read_minutes INTEGER NOT NULL DEFAULT 0,
+    enriched_at BIGINT,
+    http_status INTEGER NOT NULL DEFAULT 0,
+    broken BOOLEAN NOT NULL DEFAULT false,
+    checked_at BIGINT,
+    UNIQUE (owner_id, url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pages_owner_domain ON pages(owner_id, domain);
+CREATE INDEX IF NOT EXISTS idx_pages_owner_created_at ON pages(owner_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_pages_owner_pinned ON pages(owner_id, pinned);
+CREATE INDEX IF NOT EXISTS idx_pages_snoozed_until ON pages(snoozed_until) WHERE snoozed_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_pages_not_enriched ON pages(id) WHERE enriched_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_pages_checked_at ON pages(checked_at);
+CREATE INDEX IF NOT EXISTS idx_pages_broken ON pages(owner_id) WHERE broken;
+
+CREATE TABLE IF NOT EXISTS undo_journal (
+    id BIGSERIAL PRIMARY KEY,
+    owner_id BIGINT NOT NULL,
+    page_id BIGINT NOT NULL,
+    chat_id BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    user_name TEXT NOT NULL DEFAULT '',
+    domain TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    read_at BIGINT,
+    created_at TIMESTAMPTZ NOT NULL,
+    pinned BOOLEAN NOT NULL DEFAULT false,
+    content_kind TEXT NOT NULL DEFAULT '',
+    read_minutes INTEGER NOT NULL DEFAULT 0,
+    enriched_at BIGINT,
+    removed_at BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM now())::BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_undo_journal_owner_id ON undo_journal(owner_id, id);
+
+CREATE TABLE IF NOT EXISTS users (
+    owner_id BIGINT PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    user_name TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL DEFAULT 'user' CHECK (kind IN ('user', 'chat')),
+    shared BOOLEAN NOT NULL DEFAULT false,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    timezone TEXT NOT NULL DEFAULT 'Asia/Almaty',
+    window_start INTEGER NOT NULL DEFAULT 9 CHECK (window_start BETWEEN 0 AND 23),
+    window_end INTEGER NOT NULL DEFAULT 24 CHECK (window_end BETWEEN 1 AND 24),
+    archive BOOLEAN NOT NULL DEFAULT false,
+    lang TEXT NOT NULL DEFAULT '',
+    strategy TEXT NOT NULL DEFAULT 'random',
+    pick_cursor TEXT NOT NULL DEFAULT '',
+    send_hour INTEGER NOT NULL DEFAULT 12 CHECK (send_hour BETWEEN 0 AND 23),
+    send_minute INTEGER NOT NULL DEFAULT 0 CHECK (send_minute BETWEEN 0 AND 59),
+    last_send_at BIGINT,
+    broken_report_at BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_enabled ON users(enabled);
+
+CREATE TABLE IF NOT EXISTS bot_messages (
+    chat_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL,
+    page_id BIGINT NOT NULL,
+    PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bot_messages_page_id ON bot_messages(page_id);
+
+CREATE TABLE IF NOT EXISTS conversations (
+    chat_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    flow TEXT NOT NULL,
+    step TEXT NOT NULL DEFAULT '',
+    data TEXT NOT NULL DEFAULT '',
+    expires_at BIGINT NOT NULL,
+    PRIMARY KEY (chat_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS snapshots (
+    page_id BIGINT PRIMARY KEY,
+    title TEXT NOT NULL DEFAULT '',
+    body BYTEA NOT NULL,
+    created_at BIGINT NOT NULL
+);
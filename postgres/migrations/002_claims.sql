@@ -0,0 +1 @@
+ALTER TABLE pages ADD COLUMN claimed_until BIGINT;
\ No newline at end of file
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"embed"
+	"log"
+	"strconv"
+	"strings"
+)
+
+//go:embed queries/*.sql
+var queriesFS embed.FS
+
+func mustSQL(name string) string {
+	return rebind(readSQL(name))
+}
+
+// rebind turns ? placeholders into $1, $2, ... Queries are written with ? like the sqlite ones,
+// so filters can be appended the same way. None of them has ? in a string literal.
+func rebind(query string) string {
+	var sb strings.Builder
+	n := 0
+
+	for _, r := range query {
+		if r != '?' {
+			sb.WriteRune(r)
+			continue
+		}
+		n++
+		sb.WriteString("$" + strconv.Itoa(n))
+	}
+
+	return sb.String()
+}
+
+var (
+	qSave        = mustSQL("save.sql")
+	qIsExists    = mustSQL("is_exists.sql")
+	qRemove      = mustSQL("remove.sql")
+	qRemoveByUrl = mustSQL("remove_by_url.sql")
+
+	qUpdatePickCursor = mustSQL("update_pick_cursor.sql")
+	qUpdateNote       = mustSQL("update_note.sql")
+	qMarkRead         = mustSQL("mark_read.sql")
+	qUpdatePinned     = mustSQL("update_pinned.sql")
+
+	qClaim   = mustSQL("claim.sql")
+	qAckRead = mustSQL("ack_read.sql")
+	qRelease = mustSQL("release.sql")
+
+	qListUnenriched = mustSQL("list_unenriched.sql")
+	qUpdateContent  = mustSQL("update_content.sql")
+
+	qListUnchecked        = mustSQL("list_unchecked.sql")
+	qUpdateLinkStatus     = mustSQL("update_link_status.sql")
+	qListBrokenOwners     = mustSQL("list_broken_owners.sql")
+	qUpdateBrokenReportAt = mustSQL("update_broken_report_at.sql")
+
+	qSaveSnapshot   = mustSQL("save_snapshot.sql")
+	qGetSnapshot    = mustSQL("get_snapshot.sql")
+	qPruneSnapshots = mustSQL("prune_snapshots.sql")
+
+	qSnooze           = mustSQL("snooze.sql")
+	qListDueSnoozed   = mustSQL("list_due_snoozed.sql")
+	qClearSnooze      = mustSQL("clear_snooze.sql")
+	qSaveMessageRef   = mustSQL("save_message_ref.sql")
+	qGetMessagePage   = mustSQL("get_message_page.sql")
+	qPruneMessageRefs = mustSQL("prune_message_refs.sql")
+
+	qSetConversation    = mustSQL("set_conversation.sql")
+	qGetConversation    = mustSQL("get_conversation.sql")
+	qDeleteConversation = mustSQL("delete_conversation.sql")
+	qPruneConversations = mustSQL("prune_conversations.sql")
+
+	qJournalByID     = mustSQL("journal_by_id.sql")
+	qJournalByURL    = mustSQL("journal_by_url.sql")
+	qTrimJournal     = mustSQL("trim_journal.sql")
+	qStampJournalOp  = mustSQL("stamp_journal_op.sql")
+	qLastUndoOp      = mustSQL("last_undo_op.sql")
+	qGetUndoEntries  = mustSQL("get_undo_entries.sql")
+	qRestorePage     = mustSQL("restore_page.sql")
+	qRemoveUndoEntry = mustSQL("remove_undo_entry.sql")
+
+	qListEnabledUsers = mustSQL("list_enabled_users.sql")
+	qListUserIDs      = mustSQL("list_user_ids.sql")
+	qUpdateLastSendAt = mustSQL("update_last_send_at.sql")
+	qUpdateUserInfo   = mustSQL("update_user_info.sql")
+	qUpdateChatInfo   = mustSQL("update_chat_info.sql")
+	qUpdateSettings   = mustSQL("update_settings.sql")
+	qUpdateShared     = mustSQL("update_shared.sql")
+	qGetUserInfo      = mustSQL("get_user_info.sql")
+)
+
+// Queries completed with a filter, see compileFilter. They are rebound after the filter is appended.
+var (
+	qPickNth         = readSQL("pick_nth.sql")
+	qList            = readSQL("list.sql")
+	qCount           = readSQL("count.sql")
+	qListDomains     = readSQL("list_domains.sql")
+	qListTags        = readSQL("list_tags.sql")
+	qJournalFiltered = readSQL("journal_filtered.sql")
+	qRemoveFiltered  = readSQL("remove_filtered.sql")
+)
+
+func readSQL(name string) string {
+	sb, err := queriesFS.ReadFile("queries/" + name)
+	if err != nil {
+		log.Fatalf("missing sql file: %s: %v", name, err)
+	}
+
+	return string(sb)
+}
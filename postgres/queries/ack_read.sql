@@ -0,0 +1 @@
+UPDATE pages SET read_at = EXTRACT(EPOCH FROM now())::BIGINT, claimed_until = NULL WHERE owner_id = ? AND id = ?;
\ No newline at end of file
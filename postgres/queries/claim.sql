@@ -0,0 +1,2 @@
+UPDATE pages SET claimed_until = ?
+WHERE owner_id = ? AND id = ? AND (claimed_until IS NULL OR claimed_until <= ?);
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT count(*) FROM pages WHERE owner_id = ?
\ No newline at end of file
@@ -0,0 +1 @@
+DELETE FROM conversations WHERE chat_id = ? AND user_id = ?;
\ No newline at end of file
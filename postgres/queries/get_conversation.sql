@@ -0,0 +1 @@
+SELECT flow, step, data, expires_at FROM conversations WHERE chat_id = ? AND user_id = ? AND expires_at > ?;
\ No newline at end of file
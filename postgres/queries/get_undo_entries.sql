@@ -0,0 +1,3 @@
+SELECT id, page_id, chat_id, url, tags, note, pinned FROM undo_journal
+WHERE owner_id = ? AND (op_id = ? OR page_id = ?)
+ORDER BY id;
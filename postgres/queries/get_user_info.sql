@@ -0,0 +1,3 @@
+SELECT chat_id, user_name, timezone, enabled, send_hour, send_minute, last_send_at, kind, shared, lang,
+    window_start, window_end, archive, strategy, pick_cursor
+FROM users WHERE owner_id = ?;
\ No newline at end of file
@@ -0,0 +1,3 @@
+SELECT EXISTS (
+    SELECT 1 FROM pages WHERE owner_id = ? AND url = ?
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+INSERT INTO undo_journal (owner_id, page_id, chat_id, url, user_name, domain, tags, note, read_at, created_at, pinned, content_kind, read_minutes, enriched_at)
+SELECT owner_id, id, chat_id, url, user_name, domain, tags, note, read_at, created_at, pinned, content_kind, read_minutes, enriched_at
+FROM pages WHERE owner_id = ?
\ No newline at end of file
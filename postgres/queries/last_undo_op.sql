@@ -0,0 +1 @@
+SELECT MAX(op_id) FROM undo_journal WHERE owner_id = ?;
@@ -0,0 +1 @@
+SELECT id, url, domain, created_at, tags, note, snoozed_until, pinned, content_kind, read_minutes, broken FROM pages WHERE owner_id = ?
\ No newline at end of file
@@ -0,0 +1,4 @@
+SELECT DISTINCT p.owner_id FROM pages p
+JOIN users u ON u.owner_id = p.owner_id
+WHERE p.broken AND (u.broken_report_at IS NULL OR u.broken_report_at < ?)
+ORDER BY p.owner_id LIMIT ?;
\ No newline at end of file
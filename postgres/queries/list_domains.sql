@@ -0,0 +1 @@
+SELECT DISTINCT domain FROM pages WHERE owner_id = ?
\ No newline at end of file
@@ -0,0 +1,4 @@
+SELECT owner_id, chat_id, user_name, timezone, send_hour, send_minute, last_send_at, kind, lang,
+    window_start, window_end, archive, strategy, pick_cursor
+FROM users WHERE enabled AND (kind = 'user' OR shared)
+ORDER BY owner_id;
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT DISTINCT tags FROM pages WHERE owner_id = ? AND tags <> ''
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT owner_id FROM users ORDER BY owner_id;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE pages SET read_at = EXTRACT(EPOCH FROM now())::BIGINT WHERE id = ? AND owner_id = ?;
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT id, chat_id, url, tags, note, pinned, content_kind, read_minutes FROM pages WHERE owner_id = ?
\ No newline at end of file
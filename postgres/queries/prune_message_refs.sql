@@ -0,0 +1 @@
+DELETE FROM bot_messages m WHERE NOT EXISTS (SELECT 1 FROM pages p WHERE p.id = m.page_id);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DELETE FROM snapshots s
+WHERE NOT EXISTS (SELECT 1 FROM pages p WHERE p.id = s.page_id)
+  AND NOT EXISTS (SELECT 1 FROM undo_journal j WHERE j.page_id = s.page_id);
\ No newline at end of file
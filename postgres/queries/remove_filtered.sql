@@ -0,0 +1 @@
+DELETE FROM pages WHERE owner_id = ?
\ No newline at end of file
@@ -0,0 +1,4 @@
+INSERT INTO pages (owner_id, chat_id, url, user_name, domain, tags, note, pinned, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, COALESCE(to_timestamp(?), now()))
+ON CONFLICT (owner_id, url) DO NOTHING
+RETURNING id;
@@ -0,0 +1,2 @@
+INSERT INTO bot_messages (chat_id, message_id, page_id) VALUES (?, ?, ?)
+ON CONFLICT (chat_id, message_id) DO UPDATE SET page_id = excluded.page_id;
\ No newline at end of file
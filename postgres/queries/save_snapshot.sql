@@ -0,0 +1,5 @@
+INSERT INTO snapshots (page_id, title, body, created_at) VALUES (?, ?, ?, ?)
+ON CONFLICT (page_id) DO UPDATE SET
+    title = excluded.title,
+    body = excluded.body,
+    created_at = excluded.created_at;
\ No newline at end of file
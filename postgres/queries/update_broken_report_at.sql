@@ -0,0 +1 @@
+UPDATE users SET broken_report_at = ? WHERE owner_id = ?;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE pages SET content_kind = ?, read_minutes = ?, enriched_at = EXTRACT(EPOCH FROM now())::BIGINT WHERE id = ?;
\ No newline at end of file
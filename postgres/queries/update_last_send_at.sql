@@ -0,0 +1 @@
+UPDATE users SET last_send_at = ?, send_hour = ?, send_minute = ? WHERE owner_id = ?;
\ No newline at end of file
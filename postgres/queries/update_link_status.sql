@@ -0,0 +1 @@
+UPDATE pages SET http_status = ?, broken = ?, checked_at = ? WHERE id = ?;
\ No newline at end of file
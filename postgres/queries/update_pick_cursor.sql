@@ -0,0 +1 @@
+UPDATE users SET pick_cursor = ? WHERE owner_id = ?;
\ No newline at end of file
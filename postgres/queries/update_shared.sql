@@ -0,0 +1 @@
+UPDATE users SET shared = ? WHERE owner_id = ? AND kind = 'chat';
\ No newline at end of file
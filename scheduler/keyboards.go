@@ -0,0 +1,21 @@
+package scheduler
+
+import (
+	"narasla_bot/clients/telegram"
+	"narasla_bot/lib/i18n"
+	"strconv"
+)
+
+// snoozeCallback is handled by events/telegram, the button snoozes the page for a day.
+const snoozeCallback = "snooze"
+
+func notNowKeyboard(lang i18n.Lang, pageID int64) telegram.InlineKeyboardMarkup {
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{
+				Text:         catalog.T(lang, msgNotNowButton),
+				CallbackData: snoozeCallback + ":" + strconv.FormatInt(pageID, 10),
+			},
+		}},
+	}
+}
@@ -4,33 +4,84 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"math/rand"
+	"narasla_bot/lib/i18n"
+	"narasla_bot/lib/pagetext"
+	"narasla_bot/selector"
 	"narasla_bot/storage"
 	"strings"
 	"time"
 )
 
+// step delivers pages to users whose time has come and sends due reminders.
+// A failure for one user doesn't stop the others or the reminders, all of them are returned joined.
 func (s *Scheduler) step(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	return errors.Join(s.push(ctx, now), s.remind(ctx, now))
+}
+
+func (s *Scheduler) push(ctx context.Context, now time.Time) error {
 	users, err := s.st.ListEnabledUsers(ctx)
 	if err != nil {
 		return err
 	}
 
-	now := time.Now().UTC()
+	var errs []error
 
 	for _, u := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		ok, err := s.shouldSendNow(u, now)
 		if err != nil {
-			return err
+			errs = append(errs, fmt.Errorf("scheduler: owner=%d: %w", u.OwnerID, err))
+			continue
 		}
 		if !ok {
 			continue
 		}
 
-		if err := s.sendOne(ctx, u, now); err != nil {
-			if !errors.Is(err, storage.ErrNoSavedPages) {
-				return fmt.Errorf("scheduler: sendOne failed owner=%d: %w", u.OwnerID, err)
+		if err := s.sendOne(ctx, u, now); err != nil && !errors.Is(err, storage.ErrNoSavedPages) {
+			errs = append(errs, fmt.Errorf("scheduler: sendOne failed owner=%d: %w", u.OwnerID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// remind sends pages whose snooze is over, they stay in the list.
+// A reminder that fails is logged and tried again on the next tick.
+func (s *Scheduler) remind(ctx context.Context, now time.Time) error {
+	pages, err := s.st.ListDueSnoozed(ctx, now, remindBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range pages {
+		page := &pages[i]
+
+		lang := i18n.Default
+		if u, err := s.st.GetUserInfo(ctx, page.OwnerID); err == nil {
+			lang, _ = i18n.Parse(u.Lang)
+		}
+
+		text := catalog.T(lang, msgReminder, pagetext.Text(lang, page))
+
+		// a reminder for a chat the bot has left is dropped, the page is still in the list.
+		_, err := s.tg.SendMessageWithKeyboard(ctx, page.ChatID, text, notNowKeyboard(lang, page.ID))
+		if err != nil && !isGroupInaccessible(err) {
+			if ctx.Err() != nil {
+				return ctx.Err()
 			}
+			log.Printf("scheduler: reminder failed page=%d: %v", page.ID, err)
+			continue
+		}
+
+		if err := s.st.ClearSnooze(ctx, page); err != nil {
+			log.Printf("scheduler: can't clear snooze page=%d: %v", page.ID, err)
 		}
 	}
 
@@ -38,10 +89,7 @@ func (s *Scheduler) step(ctx context.Context) error {
 }
 
 func (s *Scheduler) shouldSendNow(u storage.User, now time.Time) (bool, error) {
-	loc, err := time.LoadLocation(u.Timezone)
-	if err != nil {
-		loc = time.UTC
-	}
+	loc := u.Location()
 
 	nowLocal := now.In(loc)
 
@@ -54,9 +102,11 @@ func (s *Scheduler) shouldSendNow(u storage.User, now time.Time) (bool, error) {
 		return false, nil
 	}
 
+	hour, minute := sendTime(u)
+
 	timeToSend := time.Date(
 		nowLocal.Year(), nowLocal.Month(), nowLocal.Day(),
-		u.SendHour, u.SendMinute, 0, 0,
+		hour, minute, 0, 0,
 		loc,
 	)
 
@@ -64,16 +114,35 @@ func (s *Scheduler) shouldSendNow(u storage.User, now time.Time) (bool, error) {
 }
 
 func (s *Scheduler) sendOne(ctx context.Context, u storage.User, now time.Time) error {
-	page, err := s.st.PickRandom(ctx, u.OwnerID)
+	page, err := selector.ClaimRandom(ctx, s.st, u, storage.Filter{})
 	if err != nil {
 		return err
 	}
 
+	lang, _ := i18n.Parse(u.Lang)
+	text := pagetext.Text(lang, page)
+
 	// hardcoded: u.ChatID if you want scheduler to send only in private.
 	// rn, it will send to the last chatID whether it is Group of Private.
-	if err := s.tg.SendMessage(ctx, page.ChatID, page.URL); err != nil {
+	if _, err := s.tg.SendMessageWithKeyboard(ctx, page.ChatID, text, notNowKeyboard(lang, page.ID)); err != nil {
+		if releaseErr := s.st.Release(ctx, page); releaseErr != nil {
+			return errors.Join(err, releaseErr)
+		}
+
+		// a shared group list has nowhere else to go, stop pushing to it.
+		if u.Kind == storage.OwnerChat && isGroupInaccessible(err) {
+			set := u.Settings
+			set.Autopush = false
+
+			if err := s.st.UpdateSettings(ctx, u.OwnerID, set); err != nil {
+				return fmt.Errorf("failed to disable autopush for chat: %w", err)
+			}
+
+			return err
+		}
+
 		if isGroupInaccessible(err) {
-			msg := "The Group is no longer accessible. Here is your page:\n" + page.URL
+			msg := catalog.T(lang, msgGroupInaccessible, text)
 			if err := s.tg.SendMessage(ctx, u.ChatID, msg); err != nil {
 				return fmt.Errorf("failed fallback to send: %w", err)
 			}
@@ -84,16 +153,42 @@ func (s *Scheduler) sendOne(ctx context.Context, u storage.User, now time.Time)
 		return err
 	}
 
-	if err := s.st.Remove(ctx, page); err != nil {
+	if err := s.st.Ack(ctx, page, u.Archive); err != nil {
 		return err
 	}
 
-	newHour := 9 + rand.Intn(15) // from 9 to 23
+	newHour := windowStart(u) + rand.Intn(windowEnd(u)-windowStart(u)) // inside the delivery window
 	newMinute := rand.Intn(60)
 
 	return s.st.UpdateLastSendAt(ctx, u.OwnerID, now.Unix(), newHour, newMinute)
 }
 
+// sendTime is when to send today, the time picked after the last send
+// can be out of the window if the window has been changed since.
+func sendTime(u storage.User) (hour, minute int) {
+	if u.SendHour < windowStart(u) || u.SendHour >= windowEnd(u) {
+		return windowStart(u), 0
+	}
+
+	return u.SendHour, u.SendMinute
+}
+
+func windowStart(u storage.User) int {
+	if u.WindowStart >= u.WindowEnd {
+		return storage.DefaultWindowStart
+	}
+
+	return u.WindowStart
+}
+
+func windowEnd(u storage.User) int {
+	if u.WindowStart >= u.WindowEnd {
+		return storage.DefaultWindowEnd
+	}
+
+	return u.WindowEnd
+}
+
 func alrSendToday(first, last time.Time) bool {
 	firstY, firstM, firstD := first.Date()
 	lastY, lastM, lastD := last.Date()
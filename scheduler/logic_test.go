@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"narasla_bot/clients/telegram"
+	"narasla_bot/storage"
+	"narasla_bot/storage/memory"
+)
+
+// fakeSender records sent texts by chat and fails for chats in failing.
+type fakeSender struct {
+	mu      sync.Mutex
+	failing map[int64]bool
+	sent    map[int64][]string
+}
+
+func (f *fakeSender) SendMessage(_ context.Context, chatID int64, text string) error {
+	_, err := f.SendMessageWithKeyboard(context.Background(), chatID, text, telegram.InlineKeyboardMarkup{})
+	return err
+}
+
+func (f *fakeSender) SendMessageWithKeyboard(_ context.Context, chatID int64, text string, _ telegram.InlineKeyboardMarkup) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failing[chatID] {
+		return 0, errors.New("api error: Internal Server Error")
+	}
+	f.sent[chatID] = append(f.sent[chatID], text)
+
+	return int64(len(f.sent[chatID])), nil
+}
+
+// addUser makes a user whose autopush is due now, with one page.
+func addUser(t *testing.T, st *memory.Storage, ownerID int64) *storage.Page {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := st.UpdateUserInfo(ctx, ownerID, ownerID, "user", "en"); err != nil {
+		t.Fatal(err)
+	}
+
+	set := storage.DefaultSettings()
+	set.Timezone, set.WindowStart, set.WindowEnd = "UTC", 0, 24
+	if err := st.UpdateSettings(ctx, ownerID, set); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateLastSendAt(ctx, ownerID, time.Now().Add(-48*time.Hour).Unix(), 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	page := &storage.Page{OwnerID: ownerID, ChatID: ownerID, URL: fmt.Sprintf("https://example.com/%d", ownerID)}
+	if err := st.Save(ctx, page); err != nil {
+		t.Fatal(err)
+	}
+
+	return page
+}
+
+func TestStepGoesOnAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+
+	addUser(t, st, 1)
+	addUser(t, st, 2)
+
+	// due reminders for both chats, of owners without autopush, so it can't pick them.
+	for _, chatID := range []int64{1, 2} {
+		p := &storage.Page{OwnerID: chatID + 10, ChatID: chatID, URL: "https://example.com/snoozed"}
+		if err := st.Save(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Snooze(ctx, p.OwnerID, p.ID, time.Now().Add(-time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tg := &fakeSender{failing: map[int64]bool{1: true}, sent: make(map[int64][]string)}
+	s := New(st, tg, time.Minute)
+
+	if err := s.step(ctx); err == nil {
+		t.Error("step doesn't report the failed delivery")
+	}
+
+	// the failed chat doesn't stop autopush and the reminder of the next one.
+	if got := len(tg.sent[2]); got != 2 {
+		t.Errorf("chat 2 got %d messages, want a page and a reminder: %q", got, tg.sent[2])
+	}
+
+	// the failed reminder stays due and is sent once the chat works again.
+	delete(tg.failing, 1)
+	if err := s.remind(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(tg.sent[1]); got != 1 {
+		t.Errorf("chat 1 got %d reminders after the failure, want 1", got)
+	}
+}
@@ -0,0 +1,22 @@
+package scheduler
+
+import "narasla_bot/lib/i18n"
+
+const (
+	msgGroupInaccessible i18n.Key = "group_inaccessible"
+	msgReminder          i18n.Key = "reminder"
+	msgNotNowButton      i18n.Key = "not_now_button"
+)
+
+var catalog = i18n.Catalog{
+	i18n.EN: {
+		msgGroupInaccessible: {"The Group is no longer accessible. Here is your page:\n%s"},
+		msgReminder:          {"Reminder, you snoozed this page:\n%s"},
+		msgNotNowButton:      {"Not now"},
+	},
+	i18n.RU: {
+		msgGroupInaccessible: {"Группа больше недоступна. Вот твоя страница:\n%s"},
+		msgReminder:          {"Напоминание, ты отложил эту страницу:\n%s"},
+		msgNotNowButton:      {"Не сейчас"},
+	},
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 )
 
+// remindBatchSize limits reminders sent in one tick, the rest go on the next ones.
+const remindBatchSize = 100
+
 type Scheduler struct {
 	st   SchedulerStorage
 	tg   Sender
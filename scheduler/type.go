@@ -2,16 +2,26 @@ package scheduler
 
 import (
 	"context"
+	"narasla_bot/clients/telegram"
+	"narasla_bot/selector"
 	"narasla_bot/storage"
+	"time"
 )
 
 type SchedulerStorage interface {
+	selector.Storage
+
 	ListEnabledUsers(ctx context.Context) ([]storage.User, error)
-	PickRandom(ctx context.Context, ownerID int64) (*storage.Page, error)
-	Remove(ctx context.Context, p *storage.Page) error
+	Ack(ctx context.Context, p *storage.Page, archive bool) error
+	Release(ctx context.Context, p *storage.Page) error
 	UpdateLastSendAt(ctx context.Context, ownerID, newTime int64, newHour, newMinute int) error
+	UpdateSettings(ctx context.Context, ownerID int64, s storage.Settings) error
+	GetUserInfo(ctx context.Context, ownerID int64) (*storage.User, error)
+	ListDueSnoozed(ctx context.Context, now time.Time, limit int) ([]storage.Page, error)
+	ClearSnooze(ctx context.Context, p *storage.Page) error
 }
 
 type Sender interface {
 	SendMessage(ctx context.Context, chatID int64, text string) error
+	SendMessageWithKeyboard(ctx context.Context, chatID int64, text string, kb telegram.InlineKeyboardMarkup) (int64, error)
 }
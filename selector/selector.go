@@ -0,0 +1,298 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"narasla_bot/storage"
+	"time"
+)
+
+// Strategy is how the next page to deliver is picked, it's a per-owner setting.
+type Strategy string
+
+const (
+	Random     Strategy = "random"     // every page is equally likely
+	Oldest     Strategy = "oldest"     // the oldest page first
+	Newest     Strategy = "newest"     // the newest page first
+	Weighted   Strategy = "weighted"   // random, the older the page the more likely
+	RoundRobin Strategy = "roundrobin" // domains in turn, a random page of the domain
+	TagTurns   Strategy = "tags"       // tags in turn, a random page of the tag
+)
+
+// Strategies in the order /settings cycles through them.
+var Strategies = []Strategy{Random, Oldest, Newest, Weighted, RoundRobin, TagTurns}
+
+// Storage is what selectors need, every pick is a Count and one PickNth
+// instead of sorting the whole list randomly.
+type Storage interface {
+	Count(ctx context.Context, ownerID int64, f storage.Filter) (int, error)
+	PickNth(ctx context.Context, ownerID int64, f storage.Filter, n int) (*storage.Page, error)
+	Domains(ctx context.Context, ownerID int64, f storage.Filter) ([]string, error)
+	Tags(ctx context.Context, ownerID int64, f storage.Filter) ([]string, error)
+	SetPickCursor(ctx context.Context, ownerID int64, cursor string) error
+	Claim(ctx context.Context, ownerID, pageID int64, until time.Time) error
+}
+
+const (
+	// Lease is how long a claimed page is hidden from other picks. A page whose delivery
+	// was neither acked nor released, e.g. the bot crashed while sending it, comes back after it.
+	Lease = time.Hour
+
+	// claimAttempts is how many times ClaimRandom picks again when another delivery
+	// claimed the picked page first.
+	claimAttempts = 5
+)
+
+// Selector picks the next page to deliver from the owner's list. Strategies that take turns
+// also return the cursor of the picked turn, the caller saves it as User.PickCursor
+// once the page is its to deliver. The cursor is nil for other strategies.
+type Selector interface {
+	Select(ctx context.Context, u storage.User, f storage.Filter) (*storage.Page, *string, error)
+}
+
+// New returns the selector of the strategy, Random for unknown ones.
+func New(st Storage, s Strategy) Selector {
+	switch s {
+	case Oldest:
+		return ordered{st: st, order: storage.OrderOldest}
+	case Newest:
+		return ordered{st: st, order: storage.OrderNewest}
+	case Weighted:
+		return weighted{st: st}
+	case RoundRobin:
+		return roundRobin{st: st}
+	case TagTurns:
+		return tagTurns{st: st}
+	default:
+		return random{st: st}
+	}
+}
+
+// Pick is what /rnd and autopush use: the owner's strategy on pages that can be delivered now,
+// pinned pages first. An explicit order in the filter ("/rnd oldest") wins over the strategy.
+func Pick(ctx context.Context, st Storage, u storage.User, f storage.Filter) (*storage.Page, error) {
+	page, cursor, err := pick(ctx, st, u, f)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := saveCursor(ctx, st, u.OwnerID, cursor); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// pick is Pick without saving the cursor.
+func pick(ctx context.Context, st Storage, u storage.User, f storage.Filter) (*storage.Page, *string, error) {
+	f.Awake = true
+	f.Unread = f.Unread || u.Archive
+
+	strategy := Strategy(u.Strategy)
+	switch f.Order {
+	case storage.OrderOldest:
+		strategy = Oldest
+	case storage.OrderNewest:
+		strategy = Newest
+	}
+
+	sel := New(st, strategy)
+
+	if !f.Pinned {
+		pinned := f
+		pinned.Pinned = true
+
+		page, cursor, err := sel.Select(ctx, u, pinned)
+		if !errors.Is(err, storage.ErrNoSavedPages) {
+			return page, cursor, err
+		}
+	}
+
+	return sel.Select(ctx, u, f)
+}
+
+// ClaimRandom picks a page like Pick, skipping pages other deliveries hold, and claims it,
+// so a concurrent /rnd and autopush never send the same page. The caller sends the page
+// and then calls storage Ack, or Release if sending failed. The turn of roundrobin and tags
+// moves on only when the claim succeeds, a lost claim picks from the same turn again.
+func ClaimRandom(ctx context.Context, st Storage, u storage.User, f storage.Filter) (*storage.Page, error) {
+	f.Free = true
+
+	for range claimAttempts {
+		page, cursor, err := pick(ctx, st, u, f)
+		if err != nil {
+			return nil, err
+		}
+
+		err = st.Claim(ctx, u.OwnerID, page.ID, time.Now().Add(Lease))
+		if errors.Is(err, storage.ErrClaimed) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := saveCursor(ctx, st, u.OwnerID, cursor); err != nil {
+			return nil, err
+		}
+
+		return page, nil
+	}
+
+	return nil, storage.ErrNoSavedPages
+}
+
+// saveCursor saves the cursor of a strategy that takes turns, nil is no cursor.
+func saveCursor(ctx context.Context, st Storage, ownerID int64, cursor *string) error {
+	if cursor == nil {
+		return nil
+	}
+
+	return st.SetPickCursor(ctx, ownerID, *cursor)
+}
+
+type random struct {
+	st Storage
+}
+
+func (s random) Select(ctx context.Context, u storage.User, f storage.Filter) (*storage.Page, *string, error) {
+	count, err := s.st.Count(ctx, u.OwnerID, f)
+	if err != nil {
+		return nil, nil, err
+	}
+	if count == 0 {
+		return nil, nil, storage.ErrNoSavedPages
+	}
+
+	f.Order = storage.OrderDefault
+
+	page, err := s.st.PickNth(ctx, u.OwnerID, f, rand.Intn(count))
+
+	return page, nil, err
+}
+
+type ordered struct {
+	st    Storage
+	order storage.Order
+}
+
+func (s ordered) Select(ctx context.Context, u storage.User, f storage.Filter) (*storage.Page, *string, error) {
+	f.Order = s.order
+
+	page, err := s.st.PickNth(ctx, u.OwnerID, f, 0)
+
+	return page, nil, err
+}
+
+type weighted struct {
+	st Storage
+}
+
+// Select picks the i-th oldest page with probability proportional to count-i,
+// so the oldest page is count times more likely than the newest one.
+func (s weighted) Select(ctx context.Context, u storage.User, f storage.Filter) (*storage.Page, *string, error) {
+	count, err := s.st.Count(ctx, u.OwnerID, f)
+	if err != nil {
+		return nil, nil, err
+	}
+	if count == 0 {
+		return nil, nil, storage.ErrNoSavedPages
+	}
+
+	n := int(float64(count) * (1 - math.Sqrt(rand.Float64())))
+	n = min(n, count-1)
+
+	f.Order = storage.OrderOldest
+
+	page, err := s.st.PickNth(ctx, u.OwnerID, f, n)
+
+	return page, nil, err
+}
+
+type roundRobin struct {
+	st Storage
+}
+
+// Select takes the domain after the one picked last time (User.PickCursor),
+// so a site with hundreds of saved pages doesn't crowd out the others.
+func (s roundRobin) Select(ctx context.Context, u storage.User, f storage.Filter) (*storage.Page, *string, error) {
+	if f.Domain != "" {
+		return random{st: s.st}.Select(ctx, u, f)
+	}
+
+	domains, err := s.st.Domains(ctx, u.OwnerID, f)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(domains) == 0 {
+		return nil, nil, storage.ErrNoSavedPages
+	}
+
+	next := nextAfter(domains, u.PickCursor)
+	f.Domain = next
+
+	page, _, err := random{st: s.st}.Select(ctx, u, f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return page, &next, nil
+}
+
+type tagTurns struct {
+	st Storage
+}
+
+// Select takes the tag after the one picked last time (User.PickCursor), like roundRobin
+// does with domains. Pages without tags get a turn of their own before the first tag,
+// its cursor is "", so they are still delivered.
+func (s tagTurns) Select(ctx context.Context, u storage.User, f storage.Filter) (*storage.Page, *string, error) {
+	if len(f.Tags) > 0 || f.Untagged {
+		return random{st: s.st}.Select(ctx, u, f)
+	}
+
+	turns, err := s.st.Tags(ctx, u.OwnerID, f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	untagged := f
+	untagged.Untagged = true
+	count, err := s.st.Count(ctx, u.OwnerID, untagged)
+	if err != nil {
+		return nil, nil, err
+	}
+	if count > 0 {
+		turns = append([]string{""}, turns...)
+	}
+	if len(turns) == 0 {
+		return nil, nil, storage.ErrNoSavedPages
+	}
+
+	next := nextAfter(turns, u.PickCursor)
+	if next == "" {
+		f.Untagged = true
+	} else {
+		f.Tags = []string{next}
+	}
+
+	page, _, err := random{st: s.st}.Select(ctx, u, f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return page, &next, nil
+}
+
+// nextAfter returns the first of the sorted turns after the cursor, the first one after the last.
+func nextAfter(turns []string, cursor string) string {
+	for _, t := range turns {
+		if t > cursor {
+			return t
+		}
+	}
+
+	return turns[0]
+}
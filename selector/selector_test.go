@@ -0,0 +1,117 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"narasla_bot/storage"
+	"narasla_bot/storage/memory"
+)
+
+// TestTagTurns checks that tags take turns however many pages each has,
+// and pages without tags get a turn too.
+func TestTagTurns(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+
+	const owner = 1
+	if err := st.UpdateUserInfo(ctx, owner, owner, "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tags := map[string][]string{"go": {"go"}, "db": {"db"}, "": nil}
+	for name, pageTags := range tags {
+		// many pages of go, so a random pick would almost never reach the others.
+		n := 1
+		if name == "go" {
+			n = 50
+		}
+		for i := range n {
+			p := &storage.Page{OwnerID: owner, ChatID: owner, URL: fmt.Sprintf("https://%s.com/%d", name, i), Tags: pageTags}
+			if err := st.Save(ctx, p); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var got []string
+	for range 6 {
+		u, err := st.GetUserInfo(ctx, owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u.Strategy = string(TagTurns)
+
+		page, err := Pick(ctx, st, *u, storage.Filter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		turn := "untagged"
+		if len(page.Tags) > 0 {
+			turn = page.Tags[0]
+		}
+		got = append(got, turn)
+	}
+
+	want := []string{"db", "go", "untagged", "db", "go", "untagged"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("turns are %v, want %v", got, want)
+	}
+}
+
+// racingStorage loses every claim, as if other deliveries always got the page first.
+type racingStorage struct {
+	*memory.Storage
+}
+
+func (s racingStorage) Claim(context.Context, int64, int64, time.Time) error {
+	return storage.ErrClaimed
+}
+
+// TestLostClaimKeepsTurn checks that the turn of the domain isn't used up
+// when no page could be claimed.
+func TestLostClaimKeepsTurn(t *testing.T) {
+	ctx := context.Background()
+	st := racingStorage{Storage: memory.New()}
+
+	const owner = 1
+	if err := st.UpdateUserInfo(ctx, owner, owner, "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, url := range []string{"https://a.com/1", "https://b.com/1"} {
+		if err := st.Save(ctx, &storage.Page{OwnerID: owner, ChatID: owner, URL: url}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	u, err := st.GetUserInfo(ctx, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Strategy = string(RoundRobin)
+
+	if _, err := ClaimRandom(ctx, st, *u, storage.Filter{}); !errors.Is(err, storage.ErrNoSavedPages) {
+		t.Fatalf("ClaimRandom: %v, want ErrNoSavedPages", err)
+	}
+
+	u, err = st.GetUserInfo(ctx, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.PickCursor != "" {
+		t.Errorf("cursor is %q after lost claims, want it unchanged", u.PickCursor)
+	}
+
+	page, err := Pick(ctx, st, *u, storage.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.URL != "https://a.com/1" {
+		t.Errorf("picked %s, want the turn of a.com", page.URL)
+	}
+}
@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Backup writes a consistent copy of the database to path, which must not exist.
+// It's VACUUM INTO on the writer connection: writes wait until the copy is done,
+// reads go on as usual. The copy is compact and has no WAL, it's a plain database file.
+func (s *Storage) Backup(ctx context.Context, path string) error {
+	if _, err := s.write.ExecContext(ctx, "VACUUM INTO ?;", path); err != nil {
+		return fmt.Errorf("can't back up database: %w", err)
+	}
+
+	return nil
+}
+
+// Verify checks that the file at path is a healthy copy of the bot's database:
+// PRAGMA integrity_check passes and it has the schema. The file is opened read-only.
+func (s *Storage) Verify(ctx context.Context, path string) error {
+	return verify(ctx, path)
+}
+
+func verify(ctx context.Context, path string) error {
+	// sqlite would create an empty database for a missing file.
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("can't open backup: %w", err)
+	}
+
+	db, err := sql.Open(driverName, withParams(path, queryOnly))
+	if err != nil {
+		return fmt.Errorf("can't open backup: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check;")
+	if err != nil {
+		return fmt.Errorf("can't check backup integrity: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return fmt.Errorf("can't check backup integrity: %w", err)
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("can't check backup integrity: %w", err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("backup %s is corrupted: %s", path, strings.Join(problems, "; "))
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version;").Scan(&version); err != nil {
+		return fmt.Errorf("can't get backup schema version: %w", err)
+	}
+	if version == 0 {
+		return fmt.Errorf("%s isn't a bot database: no schema version", path)
+	}
+
+	return nil
+}
+
+// ErrLocked is returned by Restore while the bot runs on the database, and by Lock
+// while a restore or another bot holds it.
+var ErrLocked = errors.New("database is used by the running bot, stop it first")
+
+// Lock marks the database at dbPath as used by the running bot until unlock is called,
+// so Restore refuses to replace it. The mark is the flock of dbPath.lock.
+func Lock(dbPath string) (unlock func() error, err error) {
+	f, err := lockFile(dbPath + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("can't lock database: %w", err)
+	}
+
+	return func() error { return unlockFile(f) }, nil
+}
+
+// Restore verifies the backup and puts it in place of the database at dbPath.
+// It refuses while the bot holds Lock: the old file with its WAL is replaced, not merged.
+// The backup is copied next to the database first. The old database with its -wal and -shm
+// files is moved aside to *.old, the copy is renamed in its place, and only then the old
+// files are removed. A failed restore moves them back, leaving the database as it was.
+func Restore(ctx context.Context, backupPath, dbPath string) (err error) {
+	if err := verify(ctx, backupPath); err != nil {
+		return err
+	}
+
+	unlock, err := Lock(dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = unlock() }()
+
+	src, err := os.Open(backupPath)
+	if err != nil {
+		return fmt.Errorf("can't open backup: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dbPath), filepath.Base(dbPath)+".restore*")
+	if err != nil {
+		return fmt.Errorf("can't restore database: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		return fmt.Errorf("can't copy backup: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("can't copy backup: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("can't copy backup: %w", err)
+	}
+
+	// WAL of the old database would be applied to the restored one, so it goes aside too.
+	var moved []string
+	defer func() {
+		if err != nil {
+			for _, path := range moved {
+				_ = os.Rename(path+".old", path)
+			}
+		}
+	}()
+
+	for _, path := range []string{dbPath, dbPath + "-wal", dbPath + "-shm"} {
+		err := os.Rename(path, path+".old")
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("can't move %s aside: %w", path, err)
+		}
+		moved = append(moved, path)
+	}
+
+	if err := os.Rename(tmp.Name(), dbPath); err != nil {
+		return fmt.Errorf("can't restore database: %w", err)
+	}
+
+	for _, path := range moved {
+		_ = os.Remove(path + ".old")
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"narasla_bot/storage"
+)
+
+// saveN makes a database at path with n pages of owner 1.
+func saveN(t *testing.T, path string, n int) {
+	t.Helper()
+	ctx := context.Background()
+
+	s, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if err := s.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i := range n {
+		if err := s.Save(ctx, &storage.Page{OwnerID: 1, ChatID: 1, URL: fmt.Sprintf("https://example.com/%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func countPages(t *testing.T, path string) int {
+	t.Helper()
+
+	s, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	n, err := s.Count(context.Background(), 1, storage.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func TestRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath, backupPath := filepath.Join(dir, "storage.db"), filepath.Join(dir, "backup.db")
+	saveN(t, backupPath, 1)
+	saveN(t, dbPath, 3)
+
+	// a WAL left by the old database mustn't reach the restored one.
+	if err := os.WriteFile(dbPath+"-wal", []byte("old wal"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Restore(context.Background(), backupPath, dbPath); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if n := countPages(t, dbPath); n != 1 {
+		t.Errorf("restored database has %d pages, want 1", n)
+	}
+
+	old, _ := filepath.Glob(filepath.Join(dir, "*.old"))
+	if len(old) > 0 {
+		t.Errorf("old files are left: %v", old)
+	}
+}
+
+func TestRestoreWhileRunning(t *testing.T) {
+	dir := t.TempDir()
+	dbPath, backupPath := filepath.Join(dir, "storage.db"), filepath.Join(dir, "backup.db")
+	saveN(t, backupPath, 1)
+	saveN(t, dbPath, 3)
+
+	unlock, err := Lock(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Restore(context.Background(), backupPath, dbPath); !errors.Is(err, ErrLocked) {
+		t.Errorf("Restore while locked: %v, want ErrLocked", err)
+	}
+	if n := countPages(t, dbPath); n != 3 {
+		t.Errorf("database has %d pages after a refused restore, want 3", n)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(context.Background(), backupPath, dbPath); err != nil {
+		t.Errorf("Restore after unlock: %v", err)
+	}
+}
+
+// TestRestoreRollback checks that a failed restore puts the old files back.
+func TestRestoreRollback(t *testing.T) {
+	dir := t.TempDir()
+	dbPath, backupPath := filepath.Join(dir, "storage.db"), filepath.Join(dir, "backup.db")
+	saveN(t, backupPath, 1)
+	saveN(t, dbPath, 3)
+
+	if err := os.WriteFile(dbPath+"-wal", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// the WAL can't be moved over a directory, the database is already moved by then.
+	if err := os.MkdirAll(filepath.Join(dbPath+"-wal.old", "busy"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Restore(context.Background(), backupPath, dbPath); err == nil {
+		t.Fatal("Restore: no error")
+	}
+	if _, err := os.Stat(dbPath + ".old"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the old database is left aside: %v", err)
+	}
+	if n := countPages(t, dbPath); n != 3 {
+		t.Errorf("database has %d pages after a failed restore, want 3", n)
+	}
+}
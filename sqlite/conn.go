@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// conn is a connection pool that keeps prepared statements of the hot queries.
+type conn struct {
+	*sql.DB
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// openPools opens the database twice: a writer with a single connection,
+// so writes wait for each other in Go instead of failing with "database is locked",
+// and a pool of read-only connections, which WAL lets run next to the writer.
+// An in-memory database can't be shared between pools, it gets the writer for both.
+func openPools(path string) (read, write *conn, err error) {
+	write, err = openConn(dsn(path)+"&_txlock=immediate", 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if strings.Contains(path, ":memory:") || strings.Contains(path, "mode=memory") {
+		return write, write, nil
+	}
+
+	read, err = openConn(dsn(path)+"&"+queryOnly, max(4, runtime.NumCPU()))
+	if err != nil {
+		_ = write.Close()
+		return nil, nil, err
+	}
+
+	return read, write, nil
+}
+
+func openConn(dsn string, maxConns int) (*conn, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(maxConns)
+	db.SetMaxIdleConns(maxConns)
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &conn{DB: db, stmts: make(map[string]*sql.Stmt)}, nil
+}
+
+// stmt returns the query prepared on this pool, preparing it on the first call.
+func (c *conn) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if st, ok := c.stmts[query]; ok {
+		return st, nil
+	}
+
+	st, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("can't prepare query: %w", err)
+	}
+	c.stmts[query] = st
+
+	return st, nil
+}
+
+// execPrepared runs the query as a prepared statement.
+func (c *conn) execPrepared(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	st, err := c.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return st.ExecContext(ctx, args...)
+}
+
+// queryRowPrepared runs the query as a prepared statement. If it can't be prepared,
+// the query runs as is and its Row reports the error on Scan.
+func (c *conn) queryRowPrepared(ctx context.Context, query string, args ...any) *sql.Row {
+	st, err := c.stmt(ctx, query)
+	if err != nil {
+		return c.QueryRowContext(ctx, query, args...)
+	}
+
+	return st.QueryRowContext(ctx, args...)
+}
+
+// Close closes the prepared statements and the pool.
+func (c *conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for query, st := range c.stmts {
+		_ = st.Close()
+		delete(c.stmts, query)
+	}
+
+	return c.DB.Close()
+}
@@ -0,0 +1,19 @@
+//go:build cgo && !purego
+
+package sqlite
+
+import (
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	driverName = "sqlite3"
+	queryOnly  = "_query_only=true"
+)
+
+// dsn adds the pragmas to path, the driver runs them on every new connection.
+func dsn(path string) string {
+	return withParams(path, fmt.Sprintf("_journal_mode=WAL&_busy_timeout=%d&_foreign_keys=on", busyTimeout.Milliseconds()))
+}
@@ -0,0 +1,19 @@
+//go:build !cgo || purego
+
+package sqlite
+
+import (
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	driverName = "sqlite"
+	queryOnly  = "_pragma=query_only(1)"
+)
+
+// dsn adds the pragmas to path, the driver runs them on every new connection.
+func dsn(path string) string {
+	return withParams(path, fmt.Sprintf("_pragma=journal_mode(WAL)&_pragma=busy_timeout(%d)&_pragma=foreign_keys(1)", busyTimeout.Milliseconds()))
+}
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"narasla_bot/storage"
+	"strings"
+)
+
+// compileFilter turns filter into extra WHERE conditions and ORDER BY expression.
+// User input only goes to args, conditions and order are fixed strings.
+func compileFilter(f storage.Filter) (where string, args []any, order string) {
+	var sb strings.Builder
+
+	if f.Domain != "" {
+		sb.WriteString(" AND domain = ?")
+		args = append(args, storage.NormalizeDomain(f.Domain))
+	}
+
+	if f.Unread {
+		sb.WriteString(" AND read_at IS NULL")
+	}
+
+	if f.Pinned {
+		sb.WriteString(" AND pinned = 1")
+	}
+
+	if f.Broken {
+		sb.WriteString(" AND broken = 1")
+	}
+
+	if f.Untagged {
+		sb.WriteString(" AND tags = ''")
+	}
+
+	if f.Kind != storage.KindUnknown {
+		sb.WriteString(" AND content_kind = ?")
+		args = append(args, string(f.Kind))
+	}
+
+	if f.MinMinutes > 0 || f.MaxMinutes > 0 {
+		sb.WriteString(" AND read_minutes > 0")
+	}
+
+	if f.MinMinutes > 0 {
+		sb.WriteString(" AND read_minutes >= ?")
+		args = append(args, f.MinMinutes)
+	}
+
+	if f.MaxMinutes > 0 {
+		sb.WriteString(" AND read_minutes <= ?")
+		args = append(args, f.MaxMinutes)
+	}
+
+	if f.Awake {
+		sb.WriteString(" AND (snoozed_until IS NULL OR snoozed_until <= strftime('%s', 'now'))")
+	}
+
+	if f.Free {
+		sb.WriteString(" AND (claimed_until IS NULL OR claimed_until <= strftime('%s', 'now'))")
+	}
+
+	if !f.Since.IsZero() {
+		sb.WriteString(" AND created_at >= datetime(?, 'unixepoch')")
+		args = append(args, f.Since.Unix())
+	}
+
+	if !f.Before.IsZero() {
+		sb.WriteString(" AND created_at < datetime(?, 'unixepoch')")
+		args = append(args, f.Before.Unix())
+	}
+
+	if f.IDs != nil {
+		// empty IN () is valid in sqlite and matches nothing.
+		sb.WriteString(" AND id IN (")
+		for i, id := range f.IDs {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("?")
+			args = append(args, id)
+		}
+		sb.WriteString(")")
+	}
+
+	for _, tag := range f.Tags {
+		sb.WriteString(" AND instr(tags, ?) > 0")
+		args = append(args, " "+strings.ToLower(tag)+" ")
+	}
+
+	switch f.Order {
+	case storage.OrderOldest:
+		order = "created_at ASC, id ASC"
+	case storage.OrderNewest:
+		order = "created_at DESC, id DESC"
+	default:
+		order = "id ASC"
+	}
+
+	return sb.String(), args, order
+}
+
+// encodeTags stores tags as " a b c " so a single tag can be matched with instr.
+func encodeTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	return " " + strings.ToLower(strings.Join(tags, " ")) + " "
+}
+
+func decodeTags(raw string) []string {
+	return strings.Fields(raw)
+}
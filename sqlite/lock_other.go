@@ -0,0 +1,15 @@
+//go:build !unix
+
+package sqlite
+
+import "os"
+
+// lockFile only creates the lock file where flock isn't available,
+// nothing stops a restore while the bot is running there.
+func lockFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+}
+
+func unlockFile(f *os.File) error {
+	return f.Close()
+}
@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"narasla_bot/storage"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// migrate applies every migration newer than PRAGMA user_version.
+// Each one runs in its own transaction together with the version bump.
+func (s *Storage) migrate(ctx context.Context) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	var current int
+	if err := s.write.QueryRowContext(ctx, "PRAGMA user_version;").Scan(&current); err != nil {
+		return fmt.Errorf("can't get schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		if err := s.applyMigration(ctx, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) applyMigration(ctx context.Context, m migration) (err error) {
+	tx, err := s.write.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin migration %s: %w", m.name, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, m.query); err != nil {
+		return fmt.Errorf("can't apply migration %s: %w", m.name, err)
+	}
+
+	// PRAGMA doesn't accept placeholders, version comes from the file name.
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d;", m.version)); err != nil {
+		return fmt.Errorf("can't set schema version %d: %w", m.version, err)
+	}
+
+	return tx.Commit()
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("can't read migrations: %w", err)
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("bad migration name: %s", name)
+		}
+
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("bad migration version %s: %w", name, err)
+		}
+
+		query, err := migrationsFS.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, fmt.Errorf("can't read migration %s: %w", name, err)
+		}
+
+		migrations = append(migrations, migration{
+			version: version,
+			name:    name,
+			query:   string(query),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
+
+// backfillDomains fills domain for pages saved before the column existed.
+func (s *Storage) backfillDomains(ctx context.Context) error {
+	rows, err := s.write.QueryContext(ctx, qListMissingDomains)
+	if err != nil {
+		return fmt.Errorf("can't find pages without domain: %w", err)
+	}
+
+	type pageURL struct {
+		id  int64
+		url string
+	}
+
+	var pages []pageURL
+	for rows.Next() {
+		var p pageURL
+		if err := rows.Scan(&p.id, &p.url); err != nil {
+			rows.Close()
+			return fmt.Errorf("can't scan page: %w", err)
+		}
+		pages = append(pages, p)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("can't get rows: %w", err)
+	}
+
+	for _, p := range pages {
+		domain := storage.DomainOf(p.url)
+		if domain == "" {
+			continue
+		}
+
+		if _, err := s.write.ExecContext(ctx, qUpdateDomain, domain, p.id); err != nil {
+			return fmt.Errorf("can't update page domain: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,5 @@
+ALTER TABLE pages ADD COLUMN domain TEXT NOT NULL DEFAULT '';
+ALTER TABLE pages ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+ALTER TABLE pages ADD COLUMN read_at INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_pages_owner_domain ON pages(owner_id, domain);
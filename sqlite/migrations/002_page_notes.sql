@@ -0,0 +1,10 @@
+ALTER TABLE pages ADD COLUMN note TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS bot_messages (
+    chat_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    page_id INTEGER NOT NULL,
+    PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bot_messages_page_id ON bot_messages(page_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS undo_journal (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_id INTEGER NOT NULL,
+    page_id INTEGER NOT NULL,
+    chat_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    user_name TEXT,
+    domain TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    read_at INTEGER,
+    created_at DATETIME NOT NULL,
+    removed_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_undo_journal_owner_id ON undo_journal(owner_id, id);
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN kind TEXT NOT NULL CHECK (kind IN ('user', 'chat')) DEFAULT 'user';
+ALTER TABLE users ADD COLUMN shared INTEGER NOT NULL CHECK (shared IN (0, 1)) DEFAULT 0;
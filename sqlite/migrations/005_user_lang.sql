@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN lang TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS conversations (
+    chat_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    flow TEXT NOT NULL,
+    step TEXT NOT NULL DEFAULT '',
+    data TEXT NOT NULL DEFAULT '',
+    expires_at INTEGER NOT NULL,
+    PRIMARY KEY (chat_id, user_id)
+);
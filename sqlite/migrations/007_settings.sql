@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN window_start INTEGER NOT NULL DEFAULT 9 CHECK (window_start BETWEEN 0 AND 23);
+ALTER TABLE users ADD COLUMN window_end INTEGER NOT NULL DEFAULT 24 CHECK (window_end BETWEEN 1 AND 24);
+ALTER TABLE users ADD COLUMN archive INTEGER NOT NULL DEFAULT 0 CHECK (archive IN (0, 1));
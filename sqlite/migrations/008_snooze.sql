@@ -0,0 +1,3 @@
+ALTER TABLE pages ADD COLUMN snoozed_until INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_pages_snoozed_until ON pages(snoozed_until) WHERE snoozed_until IS NOT NULL;
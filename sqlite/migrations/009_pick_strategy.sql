@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN strategy TEXT NOT NULL DEFAULT 'random';
+ALTER TABLE users ADD COLUMN pick_cursor TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_pages_owner_created_at ON pages(owner_id, created_at);
@@ -0,0 +1,4 @@
+ALTER TABLE pages ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0 CHECK (pinned IN (0, 1));
+ALTER TABLE undo_journal ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_pages_owner_pinned ON pages(owner_id, pinned);
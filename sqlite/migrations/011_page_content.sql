@@ -0,0 +1,9 @@
+ALTER TABLE pages ADD COLUMN content_kind TEXT NOT NULL DEFAULT '';
+ALTER TABLE pages ADD COLUMN read_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pages ADD COLUMN enriched_at INTEGER;
+
+ALTER TABLE undo_journal ADD COLUMN content_kind TEXT NOT NULL DEFAULT '';
+ALTER TABLE undo_journal ADD COLUMN read_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE undo_journal ADD COLUMN enriched_at INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_pages_not_enriched ON pages(id) WHERE enriched_at IS NULL;
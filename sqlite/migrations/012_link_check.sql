@@ -0,0 +1,8 @@
+ALTER TABLE pages ADD COLUMN http_status INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pages ADD COLUMN broken INTEGER NOT NULL DEFAULT 0 CHECK (broken IN (0, 1));
+ALTER TABLE pages ADD COLUMN checked_at INTEGER;
+
+ALTER TABLE users ADD COLUMN broken_report_at INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_pages_checked_at ON pages(checked_at);
+CREATE INDEX IF NOT EXISTS idx_pages_broken ON pages(owner_id) WHERE broken = 1;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS snapshots (
+    page_id INTEGER PRIMARY KEY,
+    title TEXT NOT NULL DEFAULT '',
+    body BLOB NOT NULL,
+    created_at INTEGER NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE pages ADD COLUMN claimed_until INTEGER;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE undo_journal ADD COLUMN op_id INTEGER NOT NULL DEFAULT 0;
+
+UPDATE undo_journal SET op_id = id;
+
+CREATE INDEX IF NOT EXISTS idx_undo_journal_owner_op ON undo_journal(owner_id, op_id);
@@ -19,17 +19,69 @@ func mustSQL(name string) string {
 
 var (
 	qSave        = mustSQL("save.sql")
-	qPickRandom  = mustSQL("pick_random.sql")
+	qPickNth     = mustSQL("pick_nth.sql")
 	qRemove      = mustSQL("remove.sql")
 	qIsExists    = mustSQL("is_exists.sql")
 	qRemoveByUrl = mustSQL("remove_by_url.sql")
 	qList        = mustSQL("list.sql")
 	qCount       = mustSQL("count.sql")
 
+	qListMissingDomains = mustSQL("list_missing_domains.sql")
+	qUpdateDomain       = mustSQL("update_domain.sql")
+	qListDomains        = mustSQL("list_domains.sql")
+	qListTags           = mustSQL("list_tags.sql")
+	qUpdatePickCursor   = mustSQL("update_pick_cursor.sql")
+
+	qUpdateNote   = mustSQL("update_note.sql")
+	qMarkRead     = mustSQL("mark_read.sql")
+	qUpdatePinned = mustSQL("update_pinned.sql")
+
+	qClaim   = mustSQL("claim.sql")
+	qAckRead = mustSQL("ack_read.sql")
+	qRelease = mustSQL("release.sql")
+
+	qListUnenriched = mustSQL("list_unenriched.sql")
+	qUpdateContent  = mustSQL("update_content.sql")
+
+	qListUnchecked        = mustSQL("list_unchecked.sql")
+	qUpdateLinkStatus     = mustSQL("update_link_status.sql")
+	qListBrokenOwners     = mustSQL("list_broken_owners.sql")
+	qUpdateBrokenReportAt = mustSQL("update_broken_report_at.sql")
+
+	qSaveSnapshot   = mustSQL("save_snapshot.sql")
+	qGetSnapshot    = mustSQL("get_snapshot.sql")
+	qPruneSnapshots = mustSQL("prune_snapshots.sql")
+
+	qSnooze           = mustSQL("snooze.sql")
+	qListDueSnoozed   = mustSQL("list_due_snoozed.sql")
+	qClearSnooze      = mustSQL("clear_snooze.sql")
+	qSaveMessageRef   = mustSQL("save_message_ref.sql")
+	qGetMessagePage   = mustSQL("get_message_page.sql")
+	qPruneMessageRefs = mustSQL("prune_message_refs.sql")
+
+	qSetConversation    = mustSQL("set_conversation.sql")
+	qGetConversation    = mustSQL("get_conversation.sql")
+	qDeleteConversation = mustSQL("delete_conversation.sql")
+	qPruneConversations = mustSQL("prune_conversations.sql")
+
+	qJournalByID     = mustSQL("journal_by_id.sql")
+	qJournalByURL    = mustSQL("journal_by_url.sql")
+	qTrimJournal     = mustSQL("trim_journal.sql")
+	qStampJournalOp  = mustSQL("stamp_journal_op.sql")
+	qLastUndoOp      = mustSQL("last_undo_op.sql")
+	qGetUndoEntries  = mustSQL("get_undo_entries.sql")
+	qRestorePage     = mustSQL("restore_page.sql")
+	qRemoveUndoEntry = mustSQL("remove_undo_entry.sql")
+	qJournalFiltered = mustSQL("journal_filtered.sql")
+	qRemoveFiltered  = mustSQL("remove_filtered.sql")
+
 	qListEnabledUsers = mustSQL("list_enabled_users.sql")
+	qListUserIDs      = mustSQL("list_user_ids.sql")
 	qUpdateLastSendAt = mustSQL("update_last_send_at.sql")
 	qUpdateUserInfo   = mustSQL("update_user_info.sql")
-	qUpdateEnabled    = mustSQL("update_enabled.sql")
+	qUpdateChatInfo   = mustSQL("update_chat_info.sql")
+	qUpdateSettings   = mustSQL("update_settings.sql")
+	qUpdateShared     = mustSQL("update_shared.sql")
 	qGetUserInfo      = mustSQL("get_user_info.sql")
 
 	qInit = mustSQL("init.sql")
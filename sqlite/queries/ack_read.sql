@@ -0,0 +1 @@
+UPDATE pages SET read_at = strftime('%s', 'now'), claimed_until = NULL WHERE owner_id = ? AND id = ?;
\ No newline at end of file
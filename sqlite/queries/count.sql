@@ -1 +1 @@
-SELECT Count(*) FROM pages WHERE owner_id = ?;
\ No newline at end of file
+SELECT Count(*) FROM pages WHERE owner_id = ?
\ No newline at end of file
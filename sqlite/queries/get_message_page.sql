@@ -0,0 +1,3 @@
+SELECT m.page_id FROM bot_messages m
+JOIN pages p ON p.id = m.page_id
+WHERE m.chat_id = ? AND m.message_id = ? LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+SELECT s.title, s.body, s.created_at FROM snapshots s
+JOIN pages p ON p.id = s.page_id
+WHERE p.owner_id = ? AND s.page_id = ?;
\ No newline at end of file
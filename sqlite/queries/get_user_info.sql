@@ -1,2 +1,3 @@
-SELECT timezone, enabled, send_hour, send_minute, last_send_at FROM users
-WHERE owner_id = ? LIMIT 1;
\ No newline at end of file
+SELECT chat_id, user_name, timezone, enabled, send_hour, send_minute, last_send_at, kind, shared, lang,
+    window_start, window_end, archive, strategy, pick_cursor
+FROM users WHERE owner_id = ? LIMIT 1;
\ No newline at end of file
@@ -1 +1 @@
-SELECT id, url, created_at FROM pages WHERE owner_id = ? ORDER BY id ASC LIMIT ? OFFSET ?;
\ No newline at end of file
+SELECT id, url, domain, created_at, tags, note, snoozed_until, pinned, content_kind, read_minutes, broken FROM pages WHERE owner_id = ?
\ No newline at end of file
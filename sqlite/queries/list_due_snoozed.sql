@@ -0,0 +1,3 @@
+SELECT id, owner_id, chat_id, url, tags, note, snoozed_until FROM pages
+WHERE snoozed_until IS NOT NULL AND snoozed_until <= ?
+ORDER BY snoozed_until LIMIT ?;
\ No newline at end of file
@@ -1,2 +1,3 @@
-SELECT owner_id, chat_id, user_name, timezone, send_hour, send_minute, last_send_at
-FROM users WHERE enabled = 1;
\ No newline at end of file
+SELECT owner_id, chat_id, user_name, timezone, send_hour, send_minute, last_send_at, kind, lang,
+    window_start, window_end, archive, strategy, pick_cursor
+FROM users WHERE enabled = 1 AND (kind = 'user' OR shared = 1);
\ No newline at end of file
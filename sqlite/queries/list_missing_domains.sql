@@ -0,0 +1 @@
+SELECT id, url FROM pages WHERE domain = '';
\ No newline at end of file
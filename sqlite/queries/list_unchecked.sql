@@ -0,0 +1,3 @@
+SELECT id, owner_id, chat_id, url FROM pages
+WHERE checked_at IS NULL OR checked_at < ?
+ORDER BY coalesce(checked_at, 0), id LIMIT ?;
\ No newline at end of file
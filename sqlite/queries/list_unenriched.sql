@@ -0,0 +1,3 @@
+SELECT id, owner_id, chat_id, url FROM pages
+WHERE enriched_at IS NULL
+ORDER BY id LIMIT ?;
\ No newline at end of file
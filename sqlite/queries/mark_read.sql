@@ -0,0 +1 @@
+UPDATE pages SET read_at = strftime('%s', 'now') WHERE id = ? AND owner_id = ?;
\ No newline at end of file
@@ -1 +0,0 @@
-SELECT id, chat_id, url FROM pages WHERE owner_id = ? ORDER BY RANDOM() Limit 1;
\ No newline at end of file
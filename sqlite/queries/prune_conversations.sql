@@ -0,0 +1 @@
+DELETE FROM conversations WHERE expires_at <= ?;
\ No newline at end of file
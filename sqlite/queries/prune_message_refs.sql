@@ -0,0 +1 @@
+DELETE FROM bot_messages WHERE page_id NOT IN (SELECT id FROM pages);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DELETE FROM snapshots
+WHERE page_id NOT IN (SELECT id FROM pages)
+  AND page_id NOT IN (SELECT page_id FROM undo_journal);
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE pages SET claimed_until = NULL WHERE owner_id = ? AND id = ?;
\ No newline at end of file
@@ -1 +1 @@
-DELETE FROM pages WHERE owner_id = ? AND id = ?;
\ No newline at end of file
+DELETE FROM pages WHERE owner_id = ? AND id = ? RETURNING id;
\ No newline at end of file
@@ -1 +1 @@
-DELETE FROM pages WHERE owner_id = ? AND url = ?;
\ No newline at end of file
+DELETE FROM pages WHERE owner_id = ? AND url = ? RETURNING id;
\ No newline at end of file
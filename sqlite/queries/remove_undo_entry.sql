@@ -0,0 +1 @@
+DELETE FROM undo_journal WHERE id = ?;
\ No newline at end of file
@@ -0,0 +1,3 @@
+INSERT OR IGNORE INTO pages (id, owner_id, chat_id, url, user_name, domain, tags, note, read_at, created_at, pinned, content_kind, read_minutes, enriched_at)
+SELECT page_id, owner_id, chat_id, url, user_name, domain, tags, note, read_at, created_at, pinned, content_kind, read_minutes, enriched_at
+FROM undo_journal WHERE id = ?;
@@ -1 +1,3 @@
-INSERT INTO pages (owner_id, chat_id, url, user_name) VALUES (?, ?, ?, ?);
\ No newline at end of file
+INSERT INTO pages (owner_id, chat_id, url, user_name, domain, tags, note, pinned, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, COALESCE(datetime(?, 'unixepoch'), CURRENT_TIMESTAMP))
+ON CONFLICT (owner_id, url) DO NOTHING;
@@ -0,0 +1 @@
+INSERT OR REPLACE INTO bot_messages (chat_id, message_id, page_id) VALUES (?, ?, ?);
\ No newline at end of file
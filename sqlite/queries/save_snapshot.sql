@@ -0,0 +1 @@
+INSERT OR REPLACE INTO snapshots (page_id, title, body, created_at) VALUES (?, ?, ?, ?);
\ No newline at end of file
@@ -0,0 +1,7 @@
+INSERT INTO conversations (chat_id, user_id, flow, step, data, expires_at)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (chat_id, user_id) DO UPDATE SET
+    flow = excluded.flow,
+    step = excluded.step,
+    data = excluded.data,
+    expires_at = excluded.expires_at;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE pages SET snoozed_until = ?, read_at = NULL WHERE owner_id = ? AND id = ?;
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE undo_journal SET op_id = (
+    SELECT MIN(id) FROM undo_journal WHERE owner_id = ? AND op_id = 0
+) WHERE owner_id = ? AND op_id = 0;
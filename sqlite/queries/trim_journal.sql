@@ -0,0 +1,3 @@
+DELETE FROM undo_journal WHERE owner_id = ? AND op_id NOT IN (
+    SELECT DISTINCT op_id FROM undo_journal WHERE owner_id = ? ORDER BY op_id DESC LIMIT ?
+);
@@ -0,0 +1,4 @@
+INSERT INTO users(owner_id, chat_id, user_name, kind, enabled, last_send_at)
+VALUES (?, ?, ?, 'chat', 0, strftime('%s', 'now'))
+ON CONFLICT(owner_id) DO UPDATE SET
+    user_name = excluded.user_name;
@@ -0,0 +1 @@
+UPDATE pages SET domain = ? WHERE id = ?;
\ No newline at end of file
@@ -1 +0,0 @@
-UPDATE users SET enabled = ? WHERE owner_id = ?;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE pages SET note = ? WHERE owner_id = ? AND id = ?;
\ No newline at end of file
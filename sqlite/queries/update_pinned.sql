@@ -0,0 +1 @@
+UPDATE pages SET pinned = ? WHERE owner_id = ? AND id = ?;
\ No newline at end of file
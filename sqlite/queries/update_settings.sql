@@ -0,0 +1,2 @@
+UPDATE users SET enabled = ?, timezone = ?, window_start = ?, window_end = ?, archive = ?, lang = ?, strategy = ?
+WHERE owner_id = ?;
\ No newline at end of file
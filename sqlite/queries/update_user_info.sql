@@ -1,5 +1,6 @@
-INSERT INTO users(owner_id, chat_id, user_name, last_send_at) 
-VALUES (?, ?, ?, strftime('%s', 'now'))
+INSERT INTO users(owner_id, chat_id, user_name, lang, last_send_at) 
+VALUES (?, ?, ?, ?, strftime('%s', 'now'))
 ON CONFLICT(owner_id) DO UPDATE SET 
     chat_id = excluded.chat_id,
-    user_name = excluded.user_name;
\ No newline at end of file
+    user_name = excluded.user_name,
+    lang = CASE WHEN users.lang = '' THEN excluded.lang ELSE users.lang END;
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"narasla_bot/storage"
+	"time"
+)
+
+// SaveSnapshot stores the snapshot of the page, the text is gzipped. A new snapshot replaces the old one.
+func (s *Storage) SaveSnapshot(ctx context.Context, snap *storage.Snapshot) error {
+	body, err := compress(snap.Text)
+	if err != nil {
+		return fmt.Errorf("can't compress snapshot: %w", err)
+	}
+
+	createdAt := snap.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	if _, err := s.write.ExecContext(ctx, qSaveSnapshot, snap.PageID, snap.Title, body, createdAt.Unix()); err != nil {
+		return fmt.Errorf("can't save snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Snapshot returns the snapshot of the owner's page, storage.ErrNoSnapshot if there is none.
+func (s *Storage) Snapshot(ctx context.Context, ownerID, pageID int64) (*storage.Snapshot, error) {
+	snap := storage.Snapshot{PageID: pageID}
+
+	var body []byte
+	var createdAt int64
+
+	err := s.read.QueryRowContext(ctx, qGetSnapshot, ownerID, pageID).Scan(&snap.Title, &body, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNoSnapshot
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get snapshot: %w", err)
+	}
+
+	if snap.Text, err = decompress(body); err != nil {
+		return nil, fmt.Errorf("can't decompress snapshot: %w", err)
+	}
+	snap.CreatedAt = time.Unix(createdAt, 0)
+
+	return &snap, nil
+}
+
+func compress(text string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, text); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decompress(body []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = zr.Close() }()
+
+	text, err := io.ReadAll(zr)
+
+	return string(text), err
+}
@@ -7,53 +7,107 @@ import (
 	"errors"
 	"fmt"
 	"narasla_bot/storage"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// undoJournalSize is how many removals per owner can be undone, a bulk delete is one.
+	undoJournalSize = 20
 
-	_ "github.com/mattn/go-sqlite3"
+	// busyTimeout is how long a query waits for another connection's write lock
+	// before failing with "database is locked".
+	busyTimeout = 5 * time.Second
 )
 
+// Storage works with either driver: mattn/go-sqlite3 by default or the pure Go
+// modernc.org/sqlite when built with CGO_ENABLED=0 or -tags purego.
+// Both open the database in WAL mode with busy_timeout and foreign keys on.
+// Writes go through a single connection, reads through a separate pool, see openPools.
 type Storage struct {
-	db *sql.DB
+	read  *conn
+	write *conn
 }
 
 func New(path string) (*Storage, error) {
-	db, err := sql.Open("sqlite3", path)
+	read, write, err := openPools(path)
 	if err != nil {
-		return nil, fmt.Errorf("can't open database: %w", err)
+		return nil, fmt.Errorf("can't connect database: %w", err)
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("can't connect database: %w", err)
+	return &Storage{read: read, write: write}, nil
+}
+
+func (s *Storage) Close() error {
+	if s.read == s.write {
+		return s.write.Close()
 	}
 
-	return &Storage{db: db}, nil
+	return errors.Join(s.read.Close(), s.write.Close())
 }
 
+// Save stores the page and sets page.ID to the id of the new row,
+// storage.ErrAlreadyExists if the owner has the url. A set page.CreatedAt is kept,
+// e.g. on import, the save date is now otherwise.
 func (s *Storage) Save(ctx context.Context, page *storage.Page) error {
-	if _, err := s.db.ExecContext(
+	res, err := s.write.execPrepared(
 		ctx,
 		qSave,
 		page.OwnerID,
 		page.ChatID,
 		page.URL,
 		page.UserName,
-	); err != nil {
+		storage.DomainOf(page.URL),
+		encodeTags(page.Tags),
+		page.Note,
+		boolToInt(page.Pinned),
+		nullUnix(page.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("can't save page: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
 		return fmt.Errorf("can't save page: %w", err)
 	}
+	if affected == 0 {
+		return storage.ErrAlreadyExists
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("can't get saved page id: %w", err)
+	}
+	page.ID = id
 
 	return nil
 }
 
-func (s *Storage) PickRandom(ctx context.Context, ownerID int64) (*storage.Page, error) {
+// PickNth returns the n-th page (from 0) matched by the filter in the filter order,
+// storage.ErrNoSavedPages if there are fewer pages.
+// With Count it's a random pick that doesn't sort the whole table like ORDER BY RANDOM() does.
+func (s *Storage) PickNth(ctx context.Context, ownerID int64, f storage.Filter, n int) (*storage.Page, error) {
 	var pageID int64
 	var chatId int64
 	var url string
+	var tags string
+	var note string
+	var pinned bool
+	var content storage.Content
+
+	where, filterArgs, order := compileFilter(f)
+	query := qPickNth + where + " ORDER BY " + order + " LIMIT 1 OFFSET ?;"
+	args := append([]any{ownerID}, filterArgs...)
+	args = append(args, n)
 
-	err := s.db.QueryRowContext(ctx, qPickRandom, ownerID).Scan(&pageID, &chatId, &url)
+	err := s.read.QueryRowContext(ctx, query, args...).Scan(&pageID, &chatId, &url, &tags, &note, &pinned, &content.Kind, &content.ReadMinutes)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, storage.ErrNoSavedPages
 	}
 	if err != nil {
-		return nil, fmt.Errorf("can't get random page: %w", err)
+		return nil, fmt.Errorf("can't pick page: %w", err)
 	}
 
 	return &storage.Page{
@@ -61,45 +115,245 @@ func (s *Storage) PickRandom(ctx context.Context, ownerID int64) (*storage.Page,
 		URL:     url,
 		ChatID:  chatId,
 		OwnerID: ownerID,
+		Tags:    decodeTags(tags),
+		Note:    note,
+		Pinned:  pinned,
+		Content: content,
 	}, nil
 }
 
-func (s *Storage) Remove(ctx context.Context, page *storage.Page) error {
-	res, err := s.db.ExecContext(ctx, qRemove, page.OwnerID, page.ID)
+// Domains returns distinct domains of pages matched by the filter, sorted.
+func (s *Storage) Domains(ctx context.Context, ownerID int64, f storage.Filter) ([]string, error) {
+	where, filterArgs, _ := compileFilter(f)
+	query := qListDomains + where + " ORDER BY domain;"
+	args := append([]any{ownerID}, filterArgs...)
+
+	rows, err := s.read.QueryContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("can't remove page: %w", err)
+		return nil, fmt.Errorf("can't list domains: %w", err)
 	}
+	defer rows.Close()
 
-	affected, err := res.RowsAffected()
+	var domains []string
+	for rows.Next() {
+		var domain string
+		if err := rows.Scan(&domain); err != nil {
+			return nil, fmt.Errorf("can't scan domain: %w", err)
+		}
+		domains = append(domains, domain)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get rows: %w", err)
+	}
+
+	return domains, nil
+}
+
+// Tags returns distinct tags of pages matched by the filter, sorted.
+func (s *Storage) Tags(ctx context.Context, ownerID int64, f storage.Filter) ([]string, error) {
+	where, filterArgs, _ := compileFilter(f)
+	args := append([]any{ownerID}, filterArgs...)
+
+	// tags of a page are one column, they are split here.
+	rows, err := s.read.QueryContext(ctx, qListTags+where+";", args...)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("can't list tags: %w", err)
 	}
-	if affected == 0 {
-		return storage.ErrNotFound
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("can't scan tags: %w", err)
+		}
+		tags = append(tags, decodeTags(raw)...)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get rows: %w", err)
+	}
+
+	slices.Sort(tags)
+
+	return slices.Compact(tags), nil
+}
+
+// SetPickCursor remembers where a stateful pick strategy stopped, see selector.RoundRobin.
+func (s *Storage) SetPickCursor(ctx context.Context, ownerID int64, cursor string) error {
+	if _, err := s.write.ExecContext(ctx, qUpdatePickCursor, cursor, ownerID); err != nil {
+		return fmt.Errorf("can't update pick cursor: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Storage) RemoveByURL(ctx context.Context, ownerID int64, url string) error {
-	res, err := s.db.ExecContext(ctx, qRemoveByUrl, ownerID, url)
-	if err != nil {
+// Remove deletes the page and keeps a copy in the owner's undo journal.
+func (s *Storage) Remove(ctx context.Context, page *storage.Page) error {
+	if _, err := s.removeWithJournal(ctx, qJournalByID, qRemove, page.OwnerID, page.ID); err != nil {
 		return fmt.Errorf("can't remove page: %w", err)
 	}
 
-	affected, err := res.RowsAffected()
+	return nil
+}
+
+// RemoveByURL works like Remove and returns id of the removed page, so it can be restored by Undo.
+func (s *Storage) RemoveByURL(ctx context.Context, ownerID int64, url string) (int64, error) {
+	pageID, err := s.removeWithJournal(ctx, qJournalByURL, qRemoveByUrl, ownerID, url)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("can't remove page: %w", err)
 	}
-	if affected == 0 {
-		return storage.ErrNotFound
+
+	return pageID, nil
+}
+
+// removeWithJournal copies the page to undo_journal and deletes it in one transaction.
+// Both queries take (owner_id, key) arguments, removeQuery returns id of the deleted page.
+func (s *Storage) removeWithJournal(ctx context.Context, journalQuery, removeQuery string, ownerID int64, key any) (pageID int64, err error) {
+	tx, err := s.write.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, journalQuery, ownerID, key); err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRowContext(ctx, removeQuery, ownerID, key).Scan(&pageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := closeJournalOp(ctx, tx, ownerID); err != nil {
+		return 0, err
+	}
+
+	return pageID, tx.Commit()
+}
+
+// closeJournalOp makes the pages just journaled in tx one operation, so Undo restores
+// them together, and drops the operations past undoJournalSize.
+func closeJournalOp(ctx context.Context, tx *sql.Tx, ownerID int64) error {
+	if _, err := tx.ExecContext(ctx, qStampJournalOp, ownerID, ownerID); err != nil {
+		return fmt.Errorf("can't stamp journal operation: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, qTrimJournal, ownerID, ownerID, undoJournalSize); err != nil {
+		return fmt.Errorf("can't trim journal: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Storage) List(ctx context.Context, ownerID int64, username string, limit, offset int) ([]storage.Page, error) {
-	rows, err := s.db.QueryContext(ctx, qList, ownerID, limit, offset)
+// Undo restores removed pages with their original ids and metadata: the page with pageID,
+// or with pageID 0 all pages of the owner's last removal, e.g. every page of one /del 1-5.
+// Pages whose url was saved again are skipped, storage.ErrAlreadyExists if that's all of them.
+func (s *Storage) Undo(ctx context.Context, ownerID, pageID int64) (pages []storage.Page, err error) {
+	tx, err := s.write.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't undo: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var opID int64
+	if pageID == 0 {
+		var last sql.NullInt64
+		if err := tx.QueryRowContext(ctx, qLastUndoOp, ownerID).Scan(&last); err != nil {
+			return nil, fmt.Errorf("can't get last undo operation: %w", err)
+		}
+		if !last.Valid {
+			return nil, storage.ErrNotFound
+		}
+		opID = last.Int64
+	}
+
+	entryIDs, entries, err := undoEntries(ctx, tx, ownerID, opID, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("can't get undo entries: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	for i, entryID := range entryIDs {
+		res, err := tx.ExecContext(ctx, qRestorePage, entryID)
+		if err != nil {
+			return nil, fmt.Errorf("can't restore page: %w", err)
+		}
+
+		restored, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		// the same url was saved again after removal.
+		if restored > 0 {
+			pages = append(pages, entries[i])
+		}
+
+		if _, err := tx.ExecContext(ctx, qRemoveUndoEntry, entryID); err != nil {
+			return nil, fmt.Errorf("can't remove undo entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("can't undo: %w", err)
+	}
+
+	if len(pages) == 0 {
+		return nil, storage.ErrAlreadyExists
+	}
+
+	return pages, nil
+}
+
+// undoEntries returns journal entries of the operation or of the page, oldest first.
+func undoEntries(ctx context.Context, tx *sql.Tx, ownerID, opID, pageID int64) (entryIDs []int64, pages []storage.Page, err error) {
+	rows, err := tx.QueryContext(ctx, qGetUndoEntries, ownerID, opID, pageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			entryID int64
+			tags    string
+		)
+		page := storage.Page{OwnerID: ownerID}
+
+		if err := rows.Scan(&entryID, &page.ID, &page.ChatID, &page.URL, &tags, &page.Note, &page.Pinned); err != nil {
+			return nil, nil, err
+		}
+		page.Tags = decodeTags(tags)
+
+		entryIDs = append(entryIDs, entryID)
+		pages = append(pages, page)
+	}
+
+	return entryIDs, pages, rows.Err()
+}
+
+func (s *Storage) List(ctx context.Context, ownerID int64, username string, f storage.Filter, limit, offset int) ([]storage.Page, error) {
+	where, filterArgs, order := compileFilter(f)
+	query := qList + where + " ORDER BY " + order + " LIMIT ? OFFSET ?;"
+
+	args := append([]any{ownerID}, filterArgs...)
+	args = append(args, limit, offset)
+
+	rows, err := s.read.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("can't get list: %w", err)
 	}
@@ -113,9 +367,135 @@ func (s *Storage) List(ctx context.Context, ownerID int64, username string, limi
 			UserName: username,
 		}
 
-		if err := rows.Scan(&page.ID, &page.URL, &page.CreatedAt); err != nil {
+		var tags string
+		var snoozedUntil sql.NullInt64
+		err := rows.Scan(&page.ID, &page.URL, &page.Domain, &page.CreatedAt, &tags, &page.Note, &snoozedUntil, &page.Pinned,
+			&page.Kind, &page.ReadMinutes, &page.Broken)
+		if err != nil {
 			return list, fmt.Errorf("can't scan page: %w", err)
 		}
+		page.Tags = decodeTags(tags)
+		page.SnoozedUntil = unixTime(snoozedUntil)
+		list = append(list, page)
+	}
+
+	if err = rows.Err(); err != nil {
+		return list, fmt.Errorf("can't get rows: %w", err)
+	}
+
+	return list, nil
+}
+
+// Snooze hides the page from random picks until the time, then ListDueSnoozed returns it for a reminder.
+// Snoozed page becomes unread again.
+func (s *Storage) Snooze(ctx context.Context, ownerID, pageID int64, until time.Time) error {
+	res, err := s.write.ExecContext(ctx, qSnooze, until.Unix(), ownerID, pageID)
+	if err != nil {
+		return fmt.Errorf("can't snooze page: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListDueSnoozed returns pages of all owners whose snooze is over, the earliest first.
+func (s *Storage) ListDueSnoozed(ctx context.Context, now time.Time, limit int) ([]storage.Page, error) {
+	rows, err := s.read.QueryContext(ctx, qListDueSnoozed, now.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't list snoozed pages: %w", err)
+	}
+	defer rows.Close()
+
+	var list []storage.Page
+
+	for rows.Next() {
+		var page storage.Page
+		var tags string
+		var snoozedUntil sql.NullInt64
+
+		err := rows.Scan(&page.ID, &page.OwnerID, &page.ChatID, &page.URL, &tags, &page.Note, &snoozedUntil)
+		if err != nil {
+			return list, fmt.Errorf("can't scan snoozed page: %w", err)
+		}
+		page.Tags = decodeTags(tags)
+		page.SnoozedUntil = unixTime(snoozedUntil)
+		list = append(list, page)
+	}
+
+	if err = rows.Err(); err != nil {
+		return list, fmt.Errorf("can't get rows: %w", err)
+	}
+
+	return list, nil
+}
+
+// ClearSnooze is called after the reminder about the page is sent.
+func (s *Storage) ClearSnooze(ctx context.Context, page *storage.Page) error {
+	if _, err := s.write.ExecContext(ctx, qClearSnooze, page.OwnerID, page.ID); err != nil {
+		return fmt.Errorf("can't clear snooze: %w", err)
+	}
+
+	return nil
+}
+
+// ListUnenriched returns pages of all owners whose content hasn't been detected yet, the oldest first.
+func (s *Storage) ListUnenriched(ctx context.Context, limit int) ([]storage.Page, error) {
+	rows, err := s.read.QueryContext(ctx, qListUnenriched, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't list unenriched pages: %w", err)
+	}
+	defer rows.Close()
+
+	var list []storage.Page
+
+	for rows.Next() {
+		var page storage.Page
+
+		if err := rows.Scan(&page.ID, &page.OwnerID, &page.ChatID, &page.URL); err != nil {
+			return list, fmt.Errorf("can't scan unenriched page: %w", err)
+		}
+		list = append(list, page)
+	}
+
+	if err = rows.Err(); err != nil {
+		return list, fmt.Errorf("can't get rows: %w", err)
+	}
+
+	return list, nil
+}
+
+// SetContent stores the detected content of the page, the page isn't listed as unenriched after it.
+func (s *Storage) SetContent(ctx context.Context, pageID int64, c storage.Content) error {
+	if _, err := s.write.ExecContext(ctx, qUpdateContent, string(c.Kind), c.ReadMinutes, pageID); err != nil {
+		return fmt.Errorf("can't update content: %w", err)
+	}
+
+	return nil
+}
+
+// ListUnchecked returns pages of all owners not checked since the time, never checked first.
+func (s *Storage) ListUnchecked(ctx context.Context, checkedBefore time.Time, limit int) ([]storage.Page, error) {
+	rows, err := s.read.QueryContext(ctx, qListUnchecked, checkedBefore.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't list unchecked pages: %w", err)
+	}
+	defer rows.Close()
+
+	var list []storage.Page
+
+	for rows.Next() {
+		var page storage.Page
+
+		if err := rows.Scan(&page.ID, &page.OwnerID, &page.ChatID, &page.URL); err != nil {
+			return list, fmt.Errorf("can't scan unchecked page: %w", err)
+		}
 		list = append(list, page)
 	}
 
@@ -126,10 +506,208 @@ func (s *Storage) List(ctx context.Context, ownerID int64, username string, limi
 	return list, nil
 }
 
-func (s *Storage) Count(ctx context.Context, ownerID int64) (int, error) {
+func (s *Storage) SetLinkStatus(ctx context.Context, pageID int64, ls storage.LinkStatus) error {
+	_, err := s.write.ExecContext(ctx, qUpdateLinkStatus, ls.Code, boolToInt(ls.Broken), ls.CheckedAt.Unix(), pageID)
+	if err != nil {
+		return fmt.Errorf("can't update link status: %w", err)
+	}
+
+	return nil
+}
+
+// ListBrokenOwners returns owners with broken pages who weren't told about them since the time.
+func (s *Storage) ListBrokenOwners(ctx context.Context, reportedBefore time.Time, limit int) ([]int64, error) {
+	rows, err := s.read.QueryContext(ctx, qListBrokenOwners, reportedBefore.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't list owners with broken pages: %w", err)
+	}
+	defer rows.Close()
+
+	var owners []int64
+
+	for rows.Next() {
+		var ownerID int64
+		if err := rows.Scan(&ownerID); err != nil {
+			return owners, fmt.Errorf("can't scan owner: %w", err)
+		}
+		owners = append(owners, ownerID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return owners, fmt.Errorf("can't get rows: %w", err)
+	}
+
+	return owners, nil
+}
+
+func (s *Storage) SetBrokenReportAt(ctx context.Context, ownerID int64, at time.Time) error {
+	if _, err := s.write.ExecContext(ctx, qUpdateBrokenReportAt, at.Unix(), ownerID); err != nil {
+		return fmt.Errorf("can't update broken report time: %w", err)
+	}
+
+	return nil
+}
+
+// SetPinned marks the page as high priority, pinned pages are delivered first.
+func (s *Storage) SetPinned(ctx context.Context, ownerID, pageID int64, pinned bool) error {
+	res, err := s.write.ExecContext(ctx, qUpdatePinned, boolToInt(pinned), ownerID, pageID)
+	if err != nil {
+		return fmt.Errorf("can't change pinned: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkRead keeps the delivered page in the list, but unread filters skip it.
+func (s *Storage) MarkRead(ctx context.Context, page *storage.Page) error {
+	if _, err := s.write.execPrepared(ctx, qMarkRead, page.ID, page.OwnerID); err != nil {
+		return fmt.Errorf("can't mark page as read: %w", err)
+	}
+
+	return nil
+}
+
+// Claim holds the page for a delivery until the time, so other picks skip it (Filter.Free).
+// The check and the update are one statement, of two concurrent claims only one wins,
+// the other gets storage.ErrClaimed. It's also ErrClaimed if the page is gone.
+func (s *Storage) Claim(ctx context.Context, ownerID, pageID int64, until time.Time) error {
+	res, err := s.write.ExecContext(ctx, qClaim, until.Unix(), ownerID, pageID, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("can't claim page: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrClaimed
+	}
+
+	return nil
+}
+
+// Ack finishes the delivery of the claimed page: removes it to the undo journal,
+// or with archive marks it read and frees it. A page removed during the delivery is fine.
+func (s *Storage) Ack(ctx context.Context, page *storage.Page, archive bool) error {
+	if archive {
+		if _, err := s.write.ExecContext(ctx, qAckRead, page.OwnerID, page.ID); err != nil {
+			return fmt.Errorf("can't mark page as read: %w", err)
+		}
+		return nil
+	}
+
+	_, err := s.removeWithJournal(ctx, qJournalByID, qRemove, page.OwnerID, page.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("can't remove page: %w", err)
+	}
+
+	return nil
+}
+
+// Release frees the claimed page when the delivery failed, the next pick can take it again.
+func (s *Storage) Release(ctx context.Context, page *storage.Page) error {
+	if _, err := s.write.ExecContext(ctx, qRelease, page.OwnerID, page.ID); err != nil {
+		return fmt.Errorf("can't release page: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SetNote(ctx context.Context, ownerID, pageID int64, note string) error {
+	res, err := s.write.ExecContext(ctx, qUpdateNote, note, ownerID, pageID)
+	if err != nil {
+		return fmt.Errorf("can't update note: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// SaveMessageRef remembers which page a bot message in the chat is about.
+func (s *Storage) SaveMessageRef(ctx context.Context, chatID, messageID, pageID int64) error {
+	if _, err := s.write.execPrepared(ctx, qSaveMessageRef, chatID, messageID, pageID); err != nil {
+		return fmt.Errorf("can't save message ref: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) PageIDByMessage(ctx context.Context, chatID, messageID int64) (int64, error) {
+	var pageID int64
+
+	err := s.read.queryRowPrepared(ctx, qGetMessagePage, chatID, messageID).Scan(&pageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("can't get page by message: %w", err)
+	}
+
+	return pageID, nil
+}
+
+// SetConversation starts or moves on the conversation of the user in the chat,
+// there is only one at a time.
+func (s *Storage) SetConversation(ctx context.Context, c *storage.Conversation) error {
+	_, err := s.write.execPrepared(ctx, qSetConversation,
+		c.ChatID, c.UserID, c.Flow, c.Step, c.Data, c.ExpiresAt.Unix())
+	if err != nil {
+		return fmt.Errorf("can't set conversation: %w", err)
+	}
+
+	return nil
+}
+
+// GetConversation returns storage.ErrNoConversation if there is none or it has expired.
+func (s *Storage) GetConversation(ctx context.Context, chatID, userID int64) (*storage.Conversation, error) {
+	c := storage.Conversation{ChatID: chatID, UserID: userID}
+	var expiresAt int64
+
+	err := s.read.queryRowPrepared(ctx, qGetConversation, chatID, userID, time.Now().Unix()).
+		Scan(&c.Flow, &c.Step, &c.Data, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNoConversation
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get conversation: %w", err)
+	}
+
+	c.ExpiresAt = time.Unix(expiresAt, 0)
+
+	return &c, nil
+}
+
+func (s *Storage) DeleteConversation(ctx context.Context, chatID, userID int64) error {
+	if _, err := s.write.execPrepared(ctx, qDeleteConversation, chatID, userID); err != nil {
+		return fmt.Errorf("can't delete conversation: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) Count(ctx context.Context, ownerID int64, f storage.Filter) (int, error) {
 	var count int
 
-	err := s.db.QueryRowContext(ctx, qCount, ownerID).Scan(&count)
+	where, filterArgs, _ := compileFilter(f)
+	args := append([]any{ownerID}, filterArgs...)
+
+	err := s.read.QueryRowContext(ctx, qCount+where+";", args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("can't count pages: %w", err)
 	}
@@ -137,11 +715,52 @@ func (s *Storage) Count(ctx context.Context, ownerID int64) (int, error) {
 	return count, nil
 }
 
+// RemoveFiltered removes every page matching the filter in one transaction
+// and returns how many were removed. Removed pages go to the undo journal.
+func (s *Storage) RemoveFiltered(ctx context.Context, ownerID int64, f storage.Filter) (removed int, err error) {
+	where, filterArgs, _ := compileFilter(f)
+	args := append([]any{ownerID}, filterArgs...)
+
+	tx, err := s.write.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("can't remove pages: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, qJournalFiltered+where+";", args...); err != nil {
+		return 0, fmt.Errorf("can't journal pages: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, qRemoveFiltered+where+";", args...)
+	if err != nil {
+		return 0, fmt.Errorf("can't remove pages: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := closeJournalOp(ctx, tx, ownerID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("can't remove pages: %w", err)
+	}
+
+	return int(affected), nil
+}
+
 // IsExists checks if page exists in storage.
 func (s *Storage) IsExists(ctx context.Context, ownerID int64, url string) (bool, error) {
 	var count int
 
-	if err := s.db.QueryRowContext(ctx, qIsExists, ownerID, url).Scan(&count); err != nil {
+	if err := s.read.queryRowPrepared(ctx, qIsExists, ownerID, url).Scan(&count); err != nil {
 		return false, fmt.Errorf("can't check page exists: %w", err)
 	}
 
@@ -149,15 +768,36 @@ func (s *Storage) IsExists(ctx context.Context, ownerID int64, url string) (bool
 }
 
 func (s *Storage) Init(ctx context.Context) error {
-	if _, err := s.db.ExecContext(ctx, qInit); err != nil {
+	if _, err := s.write.ExecContext(ctx, qInit); err != nil {
 		return fmt.Errorf("can't create table: %w", err)
 	}
 
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+
+	if err := s.backfillDomains(ctx); err != nil {
+		return err
+	}
+
+	if _, err := s.write.ExecContext(ctx, qPruneMessageRefs); err != nil {
+		return fmt.Errorf("can't prune message refs: %w", err)
+	}
+
+	if _, err := s.write.ExecContext(ctx, qPruneConversations, time.Now().Unix()); err != nil {
+		return fmt.Errorf("can't prune conversations: %w", err)
+	}
+
+	// snapshots of pages removed for good, also out of the undo journal.
+	if _, err := s.write.ExecContext(ctx, qPruneSnapshots); err != nil {
+		return fmt.Errorf("can't prune snapshots: %w", err)
+	}
+
 	return nil
 }
 
 func (s *Storage) ListEnabledUsers(ctx context.Context) ([]storage.User, error) {
-	rows, err := s.db.QueryContext(ctx, qListEnabledUsers)
+	rows, err := s.read.QueryContext(ctx, qListEnabledUsers)
 	if err != nil {
 		return nil, fmt.Errorf("can't find enabled users: %w", err)
 	}
@@ -174,10 +814,20 @@ func (s *Storage) ListEnabledUsers(ctx context.Context) ([]storage.User, error)
 			&user.SendHour,
 			&user.SendMinute,
 			&user.LastSendAt,
+			&user.Kind,
+			&user.Lang,
+			&user.WindowStart,
+			&user.WindowEnd,
+			&user.Archive,
+			&user.Strategy,
+			&user.PickCursor,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("can't scan enabled users: %w", err)
 		}
+		// the query returns only chats with the shared list turned on.
+		user.Autopush = true
+		user.Shared = user.Kind == storage.OwnerChat
 		if user.ChatID == 0 {
 			continue
 		}
@@ -191,29 +841,112 @@ func (s *Storage) ListEnabledUsers(ctx context.Context) ([]storage.User, error)
 	return enabledUsers, nil
 }
 
+// ListUsers returns every user and group chat, ordered by owner id. It serves admin commands
+// and reads each one with GetUserInfo.
+func (s *Storage) ListUsers(ctx context.Context) ([]storage.User, error) {
+	rows, err := s.read.QueryContext(ctx, qListUserIDs)
+	if err != nil {
+		return nil, fmt.Errorf("can't list users: %w", err)
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("can't scan user id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't get rows: %w", err)
+	}
+
+	users := make([]storage.User, 0, len(ids))
+	for _, id := range ids {
+		u, err := s.GetUserInfo(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+
+	return users, nil
+}
+
 func (s *Storage) UpdateLastSendAt(ctx context.Context, ownerID, newTime int64, newHour, newMinute int) error {
-	if _, err := s.db.ExecContext(ctx, qUpdateLastSendAt, newTime, newHour, newMinute, ownerID); err != nil {
+	if _, err := s.write.execPrepared(ctx, qUpdateLastSendAt, newTime, newHour, newMinute, ownerID); err != nil {
 		return fmt.Errorf("can't update last send at for user: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Storage) UpdateUserInfo(ctx context.Context, ownerID, chatID int64, username string) error {
-	if _, err := s.db.ExecContext(ctx, qUpdateUserInfo, ownerID, chatID, username); err != nil {
+// UpdateUserInfo creates the user or updates their chat and username.
+// lang is saved only if the user has no language yet.
+func (s *Storage) UpdateUserInfo(ctx context.Context, ownerID, chatID int64, username, lang string) error {
+	if _, err := s.write.execPrepared(ctx, qUpdateUserInfo, ownerID, chatID, username, lang); err != nil {
 		return fmt.Errorf("can't update user info: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Storage) SwitchEnable(ctx context.Context, ownerID int64, enabled bool) error {
-	enabledForm := 0
-	if enabled {
-		enabledForm = 1
+// UpdateChatInfo registers a group chat as a list owner, autopush is off for new chats.
+func (s *Storage) UpdateChatInfo(ctx context.Context, chatID int64, title string) error {
+	if _, err := s.write.ExecContext(ctx, qUpdateChatInfo, chatID, chatID, title); err != nil {
+		return fmt.Errorf("can't update chat info: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SwitchShared(ctx context.Context, chatID int64, shared bool) error {
+	sharedForm := 0
+	if shared {
+		sharedForm = 1
+	}
+
+	res, err := s.write.ExecContext(ctx, qUpdateShared, sharedForm, chatID)
+	if err != nil {
+		return fmt.Errorf("can't change shared for chat: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateSettings saves all settings at once, see storage.Settings.
+func (s *Storage) UpdateSettings(ctx context.Context, ownerID int64, set storage.Settings) error {
+	res, err := s.write.ExecContext(ctx, qUpdateSettings,
+		boolToInt(set.Autopush),
+		set.Timezone,
+		set.WindowStart,
+		set.WindowEnd,
+		boolToInt(set.Archive),
+		set.Lang,
+		set.Strategy,
+		ownerID,
+	)
+	if err != nil {
+		return fmt.Errorf("can't update settings: %w", err)
 	}
-	if _, err := s.db.ExecContext(ctx, qUpdateEnabled, enabledForm, ownerID); err != nil {
-		return fmt.Errorf("can't change enabled for user: %w", err)
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrUserNotFound
 	}
 
 	return nil
@@ -221,19 +954,39 @@ func (s *Storage) SwitchEnable(ctx context.Context, ownerID int64, enabled bool)
 
 func (s *Storage) GetUserInfo(ctx context.Context, ownerID int64) (*storage.User, error) {
 	var (
+		chatID      int64
+		username    sql.NullString
 		timezone    string
 		enabledForm int
 		sendHour    int
 		sendMinute  int
 		lastSendAt  sql.NullInt64
+		kind        storage.OwnerKind
+		sharedForm  int
+		lang        string
+		windowStart int
+		windowEnd   int
+		archiveForm int
+		strategy    string
+		pickCursor  string
 	)
 
-	err := s.db.QueryRowContext(ctx, qGetUserInfo, ownerID).Scan(
+	err := s.read.queryRowPrepared(ctx, qGetUserInfo, ownerID).Scan(
+		&chatID,
+		&username,
 		&timezone,
 		&enabledForm,
 		&sendHour,
 		&sendMinute,
 		&lastSendAt,
+		&kind,
+		&sharedForm,
+		&lang,
+		&windowStart,
+		&windowEnd,
+		&archiveForm,
+		&strategy,
+		&pickCursor,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, storage.ErrUserNotFound
@@ -242,14 +995,58 @@ func (s *Storage) GetUserInfo(ctx context.Context, ownerID int64) (*storage.User
 		return nil, fmt.Errorf("can't get user info: %w", err)
 	}
 
-	enabled := enabledForm == 1
-
 	return &storage.User{
-		OwnerID:    ownerID,
-		Timezone:   timezone,
-		Enabled:    enabled,
+		OwnerID:  ownerID,
+		ChatID:   chatID,
+		Username: username.String,
+		Kind:     kind,
+		Shared:   sharedForm == 1,
+		Settings: storage.Settings{
+			Autopush:    enabledForm == 1,
+			Timezone:    timezone,
+			WindowStart: windowStart,
+			WindowEnd:   windowEnd,
+			Archive:     archiveForm == 1,
+			Lang:        lang,
+			Strategy:    strategy,
+		},
+		PickCursor: pickCursor,
 		SendHour:   sendHour,
 		SendMinute: sendMinute,
 		LastSendAt: lastSendAt,
 	}, nil
 }
+
+// withParams appends query parameters to the database path, which may have its own.
+func withParams(path, params string) string {
+	if strings.Contains(path, "?") {
+		return path + "&" + params
+	}
+
+	return path + "?" + params
+}
+
+func unixTime(t sql.NullInt64) time.Time {
+	if !t.Valid {
+		return time.Time{}
+	}
+
+	return time.Unix(t.Int64, 0)
+}
+
+// nullUnix is the reverse of unixTime: NULL for the zero time.
+func nullUnix(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: t.Unix(), Valid: true}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,200 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"narasla_bot/storage"
+	"narasla_bot/storage/storagetest"
+)
+
+// openTemp opens a migrated storage in a new file under t.TempDir.
+func openTemp(t *testing.T) *Storage {
+	t.Helper()
+
+	s, err := open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+func open(root string) (*Storage, error) {
+	dir, err := os.MkdirTemp(root, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := New(filepath.Join(dir, "storage.db"))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Init(context.Background()); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func TestStorage(t *testing.T) {
+	root := t.TempDir()
+
+	openFn := func() (storage.Storage, func() error, error) {
+		s, err := open(root)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, s.Close, nil
+	}
+
+	if err := storagetest.Run(context.Background(), openFn); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestConcurrentWrites mixes plain writes with Remove and Undo, whose transactions
+// read before they write: on a pool of writers they fail with "database is locked".
+func TestConcurrentWrites(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+
+	const workers, pages = 16, 30
+
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			ownerID := int64(w + 1)
+			for i := 0; i < pages; i++ {
+				p := &storage.Page{OwnerID: ownerID, ChatID: ownerID, URL: fmt.Sprintf("https://example.com/%d/%d", w, i)}
+				if err := s.Save(ctx, p); err != nil {
+					errs <- err
+					return
+				}
+				if err := s.UpdateUserInfo(ctx, ownerID, ownerID, "user", "en"); err != nil {
+					errs <- err
+					return
+				}
+				if err := s.MarkRead(ctx, p); err != nil {
+					errs <- err
+					return
+				}
+				if err := s.Remove(ctx, p); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := s.Undo(ctx, ownerID, p.ID); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}(w)
+	}
+
+	for w := 0; w < workers; w++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	for w := 0; w < workers; w++ {
+		n, err := s.Count(ctx, int64(w+1), storage.Filter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != pages {
+			t.Errorf("owner %d has %d pages, want %d", w+1, n, pages)
+		}
+	}
+}
+
+func TestReadDuringWrite(t *testing.T) {
+	s := openTemp(t)
+	ctx := context.Background()
+
+	if err := s.Save(ctx, &storage.Page{OwnerID: 1, ChatID: 1, URL: "https://example.com/1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := s.write.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, qSave, 1, 1, "https://example.com/2", "", "example.com", "", "", 0, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the writer holds the lock, a read must not wait for busy_timeout.
+	readCtx, cancel := context.WithTimeout(ctx, busyTimeout/2)
+	defer cancel()
+
+	n, err := s.Count(readCtx, 1, storage.Filter{})
+	if err != nil {
+		t.Fatalf("read during a write transaction: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("read sees %d pages before commit, want 1", n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err = s.Count(ctx, 1, storage.Filter{}); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("read sees %d pages after commit, want 2", n)
+	}
+}
+
+func TestPreparedStatements(t *testing.T) {
+	s, err := open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	p := &storage.Page{OwnerID: 1, ChatID: 1, URL: "https://example.com"}
+	if err := s.Save(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.IsExists(ctx, 1, p.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := s.write.stmt(ctx, qSave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := s.write.stmt(ctx, qSave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != again {
+		t.Error("a hot query is prepared again instead of reused")
+	}
+
+	if len(s.write.stmts) == 0 || len(s.read.stmts) == 0 {
+		t.Fatalf("no prepared statements: writer %d, reader %d", len(s.write.stmts), len(s.read.stmts))
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.write.stmts) != 0 || len(s.read.stmts) != 0 {
+		t.Errorf("statements left after Close: writer %d, reader %d", len(s.write.stmts), len(s.read.stmts))
+	}
+	if _, err := first.ExecContext(ctx, 1, 1, "https://example.com/2", "", "", "", "", 0, nil); err == nil {
+		t.Error("a statement works after Close")
+	}
+}
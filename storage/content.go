@@ -0,0 +1,20 @@
+package storage
+
+// ContentKind is what a saved link points to, it's detected after saving.
+type ContentKind string
+
+const (
+	KindUnknown ContentKind = "" // not detected yet or the page can't be fetched
+	KindArticle ContentKind = "article"
+	KindVideo   ContentKind = "video"
+	KindPDF     ContentKind = "pdf"
+	KindRepo    ContentKind = "repo"
+	KindTweet   ContentKind = "tweet"
+	KindOther   ContentKind = "other"
+)
+
+// Content describes the page behind the link, see the enricher package.
+type Content struct {
+	Kind        ContentKind
+	ReadMinutes int // estimated reading time of an article, 0 if unknown
+}
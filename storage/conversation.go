@@ -0,0 +1,14 @@
+package storage
+
+import "time"
+
+// Conversation is the state of a multi-step dialog with a user in a chat:
+// the bot asked something and waits for the answer.
+type Conversation struct {
+	ChatID    int64
+	UserID    int64
+	Flow      string // which dialog it is, e.g. "save"
+	Step      string // where the dialog is, meaning depends on the flow
+	Data      string // answers collected so far, format depends on the flow
+	ExpiresAt time.Time
+}
@@ -5,166 +5,210 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
-	"math/rand"
 	"narasla_bot/lib/e"
 	"narasla_bot/storage"
+	"narasla_bot/storage/memory"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 )
 
+// Storage keeps everything in gob files under basePath:
+//
+//	meta.gob                       — id counters
+//	users.gob                      — users and group chats with their settings
+//	conversations.gob, refs.gob    — dialogs in progress and "Saved!" messages
+//	owners/<ownerID>/index.gob     — pages of the owner, in id order
+//	owners/<ownerID>/journal.gob   — removed pages /undo can restore
+//	snapshots/<pageID>.gob         — readable copies of pages, gzipped
+//
+// The files are read once in New into a memory.Storage, lookups don't touch the disk.
+// Every change rewrites the files it affects atomically, so a crash leaves either the old or the new one.
+// A change to one page rewrites the whole index of its owner, which is fine for hundreds of pages, not for many thousands.
+// The directory is locked, only one process can use it.
 type Storage struct {
-	basePath string
-}
-
-const defaultPerm = 0774
+	*memory.Storage
 
-var userDirNotFound = errors.New("user's directory not found")
-
-func New(basePath string) Storage {
-	return Storage{basePath: basePath}
+	lock *os.File
 }
 
-func (s Storage) Save(page *storage.Page) (err error) {
-	defer func() { err = e.Wrap("Storage: save Page failed", err) }()
+const (
+	defaultPerm = 0774
+	filePerm    = 0664
+)
+
+var ErrLocked = errors.New("Storage: directory is used by another process")
 
-	fPath := filepath.Join(s.basePath, page.UserName)
+// New opens the storage in basePath, creating it if needed, and locks it until Close.
+func New(basePath string) (s *Storage, err error) {
+	defer func() { err = e.Wrap("Storage: can't open files storage", err) }()
 
-	if err := os.MkdirAll(fPath, defaultPerm); err != nil {
-		return err
+	for _, dir := range []string{basePath, filepath.Join(basePath, "owners"), filepath.Join(basePath, "snapshots")} {
+		if err := os.MkdirAll(dir, defaultPerm); err != nil {
+			return nil, err
+		}
 	}
 
-	fName, err := fileName(page)
+	lock, err := lockDir(basePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fPath = filepath.Join(fPath, fName)
+	d := disk{basePath: basePath}
 
-	file, err := os.Create(fPath)
+	state, err := d.load()
 	if err != nil {
-		return err
+		_ = unlockDir(lock)
+		return nil, err
 	}
 
-	defer func() { _ = file.Close() }()
+	s = &Storage{Storage: memory.NewWith(state, d), lock: lock}
 
-	if err := gob.NewEncoder(file).Encode(page); err != nil {
-		return err
+	if err := s.Prune(time.Now()); err != nil {
+		_ = unlockDir(lock)
+		return nil, err
 	}
 
-	return nil
+	return s, nil
 }
 
-func (s Storage) PickRandom(userName string) (page *storage.Page, err error) {
-	defer func() { err = e.Wrap("Storage: PickRandom failed", err) }()
+// Close releases the directory lock, the storage can't be used after it.
+func (s *Storage) Close() error {
+	return unlockDir(s.lock)
+}
 
-	path, err := searchUserDir(s.basePath, userName)
-	if err != nil {
-		if errors.Is(err, userDirNotFound) {
-			return nil, storage.ErrNoSavedPages
-		}
+// disk is the memory.Persister that writes gob files.
+type disk struct {
+	basePath string
+}
 
-		return nil, err
-	}
+func (d disk) load() (memory.State, error) {
+	state := memory.State{Owners: make(map[int64]*memory.Owner)}
 
-	files, err := os.ReadDir(path)
-	if err != nil {
-		return nil, err
+	if err := readGob(d.path("meta.gob"), &state.Meta); err != nil {
+		return state, err
+	}
+	if err := readGob(d.path("users.gob"), &state.Users); err != nil {
+		return state, err
+	}
+	if err := readGob(d.path("conversations.gob"), &state.Conversations); err != nil {
+		return state, err
+	}
+	if err := readGob(d.path("refs.gob"), &state.Refs); err != nil {
+		return state, err
 	}
 
-	if len(files) == 0 {
-		return nil, storage.ErrNoSavedPages
+	dirs, err := os.ReadDir(d.path("owners"))
+	if err != nil {
+		return state, err
 	}
 
-	source := rand.NewSource(time.Now().Unix())
-	r := rand.New(source)
+	for _, dir := range dirs {
+		ownerID, err := strconv.ParseInt(dir.Name(), 10, 64)
+		if err != nil || !dir.IsDir() {
+			continue
+		}
 
-	n := r.Intn(len(files))
+		o := &memory.Owner{}
+		if err := readGob(d.ownerPath(ownerID, "index.gob"), &o.Pages); err != nil {
+			return state, err
+		}
+		if err := readGob(d.ownerPath(ownerID, "journal.gob"), &o.Journal); err != nil {
+			return state, err
+		}
 
-	file := files[n]
+		state.Owners[ownerID] = o
+	}
 
-	return s.decodePage(filepath.Join(path, file.Name()))
+	return state, nil
 }
 
-func searchUserDir(root, username string) (string, error) {
-	var foundDir string
-	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if d.IsDir() && d.Name() == username {
-			foundDir = path
-			return fs.SkipAll
-		}
-
-		return nil
-	})
+func (d disk) SaveMeta(m memory.Meta) error {
+	return writeGob(d.path("meta.gob"), m)
+}
 
-	if err != nil {
-		return "", err
-	}
+func (d disk) SaveUsers(users []*memory.User) error {
+	return writeGob(d.path("users.gob"), users)
+}
 
-	if foundDir == "" {
-		return "", userDirNotFound
+func (d disk) SavePages(ownerID int64, pages []memory.Record) error {
+	if err := os.MkdirAll(d.path("owners", strconv.FormatInt(ownerID, 10)), defaultPerm); err != nil {
+		return err
 	}
 
-	return foundDir, nil
+	return writeGob(d.ownerPath(ownerID, "index.gob"), pages)
 }
 
-func (s Storage) Remove(p *storage.Page) error {
-	fileName, err := fileName(p)
-	if err != nil {
-		return e.Wrap("Storage: Remove page failed", err)
+func (d disk) SaveJournal(ownerID int64, journal []memory.Entry) error {
+	if err := os.MkdirAll(d.path("owners", strconv.FormatInt(ownerID, 10)), defaultPerm); err != nil {
+		return err
 	}
 
-	path := filepath.Join(s.basePath, p.UserName, fileName)
+	return writeGob(d.ownerPath(ownerID, "journal.gob"), journal)
+}
 
-	if err := os.Remove(path); err != nil {
-		msg := fmt.Sprintf("Storage: Remove page failed %s", path)
+func (d disk) SaveConversations(convs []storage.Conversation) error {
+	return writeGob(d.path("conversations.gob"), convs)
+}
 
-		return e.Wrap(msg, err)
-	}
+func (d disk) SaveRefs(refs []memory.MessageRef) error {
+	return writeGob(d.path("refs.gob"), refs)
+}
 
-	return nil
+func (d disk) path(elem ...string) string {
+	return filepath.Join(append([]string{d.basePath}, elem...)...)
 }
 
-func (s Storage) IsExists(p *storage.Page) (bool, error) {
-	fileName, err := fileName(p)
+func (d disk) ownerPath(ownerID int64, name string) string {
+	return d.path("owners", strconv.FormatInt(ownerID, 10), name)
+}
+
+// readGob decodes the file into v, a missing file leaves v as is.
+func readGob(path string, v any) error {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
-		return false, e.Wrap("Storage: IsExists failed to find fileName", err)
+		return err
 	}
 
-	path := filepath.Join(s.basePath, p.UserName, fileName)
+	defer func() { _ = file.Close() }()
 
-	switch _, err := os.Stat(path); {
-	case errors.Is(err, os.ErrNotExist):
-		return false, nil
-	case err != nil:
-		msg := fmt.Sprintf("Storage: IsExists failed to check if file %s exists", path)
-		return false, e.Wrap(msg, err)
+	if err := gob.NewDecoder(file).Decode(v); err != nil {
+		return fmt.Errorf("can't decode %s: %w", path, err)
 	}
 
-	return true, nil
+	return nil
 }
 
-func (s Storage) decodePage(filePath string) (*storage.Page, error) {
-	file, err := os.Open(filePath)
+// writeGob replaces the file atomically: it writes a temporary file next to it,
+// syncs it to disk and renames it over the old one.
+func writeGob(path string, v any) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
 	if err != nil {
-		return nil, e.Wrap("Storage: PickRandom decodePage failed", err)
+		return err
 	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
 
-	defer func() { _ = file.Close() }()
-
-	var p storage.Page
-
-	if err := gob.NewDecoder(file).Decode(&p); err != nil {
-		return nil, e.Wrap("Storage: PickRandom decodePage failed", err)
+	if err := gob.NewEncoder(tmp).Encode(v); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), filePerm); err != nil {
+		return err
 	}
 
-	return &p, nil
-}
-
-func fileName(p *storage.Page) (string, error) {
-	return p.Hash()
+	return os.Rename(tmp.Name(), path)
 }
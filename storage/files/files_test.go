@@ -0,0 +1,32 @@
+package files
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"narasla_bot/storage"
+	"narasla_bot/storage/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	root := t.TempDir()
+
+	open := func() (storage.Storage, func() error, error) {
+		dir, err := os.MkdirTemp(root, "files")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		s, err := New(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return s, s.Close, nil
+	}
+
+	if err := storagetest.Run(context.Background(), open); err != nil {
+		t.Fatal(err)
+	}
+}
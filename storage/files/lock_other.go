@@ -0,0 +1,18 @@
+//go:build !unix
+
+package files
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// lockDir only creates the lock file where flock isn't available,
+// nothing stops a second process there.
+func lockDir(basePath string) (*os.File, error) {
+	return os.OpenFile(filepath.Join(basePath, ".lock"), os.O_CREATE|os.O_RDWR, filePerm)
+}
+
+func unlockDir(f *os.File) error {
+	return f.Close()
+}
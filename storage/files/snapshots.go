@@ -0,0 +1,100 @@
+package files
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"narasla_bot/storage"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// snapshotFile is a snapshot on disk, the text is gzipped.
+type snapshotFile struct {
+	Title     string
+	Body      []byte
+	CreatedAt time.Time
+}
+
+func (d disk) SaveSnapshot(snap *storage.Snapshot) error {
+	body, err := compress(snap.Text)
+	if err != nil {
+		return err
+	}
+
+	return writeGob(d.snapshotPath(snap.PageID), snapshotFile{
+		Title:     snap.Title,
+		Body:      body,
+		CreatedAt: snap.CreatedAt,
+	})
+}
+
+func (d disk) LoadSnapshot(pageID int64) (*storage.Snapshot, error) {
+	var f snapshotFile
+	if err := readGob(d.snapshotPath(pageID), &f); err != nil {
+		return nil, err
+	}
+	if f.Body == nil {
+		return nil, storage.ErrNoSnapshot
+	}
+
+	text, err := decompress(f.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.Snapshot{PageID: pageID, Title: f.Title, Text: text, CreatedAt: f.CreatedAt}, nil
+}
+
+func (d disk) PruneSnapshots(keep func(pageID int64) bool) error {
+	files, err := os.ReadDir(d.path("snapshots"))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		pageID, err := strconv.ParseInt(strings.TrimSuffix(f.Name(), ".gob"), 10, 64)
+		if err != nil || keep(pageID) {
+			continue
+		}
+		if err := os.Remove(d.path("snapshots", f.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d disk) snapshotPath(pageID int64) string {
+	return d.path("snapshots", strconv.FormatInt(pageID, 10)+".gob")
+}
+
+func compress(text string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, text); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decompress(body []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = zr.Close() }()
+
+	text, err := io.ReadAll(zr)
+
+	return string(text), err
+}
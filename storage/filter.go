@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Order int
+
+const (
+	OrderDefault Order = iota // by id, the order pages were saved
+	OrderOldest
+	OrderNewest
+)
+
+// Filter narrows down pages for List, PickNth, Count and RemoveFiltered.
+// Zero value matches every page of the owner.
+type Filter struct {
+	Order    Order
+	Domain   string
+	Unread   bool
+	Awake    bool // skip pages snoozed until later
+	Free     bool // skip pages claimed by a delivery in progress
+	Pinned   bool // only high priority pages
+	Broken   bool // only pages with dead links
+	Kind     ContentKind
+	Since    time.Time // zero means no lower bound
+	Before   time.Time // zero means no upper bound
+	Tags     []string  // page must have all of them
+	Untagged bool      // only pages without tags
+	IDs      []int64   // nil means any page
+
+	// Reading time bounds in minutes, 0 means no bound.
+	// Any bound skips pages with unknown reading time.
+	MinMinutes int
+	MaxMinutes int
+}
+
+func (f Filter) IsEmpty() bool {
+	return f.Order == OrderDefault &&
+		f.Domain == "" &&
+		!f.Unread &&
+		!f.Awake &&
+		!f.Free &&
+		!f.Pinned &&
+		!f.Broken &&
+		f.Kind == KindUnknown &&
+		f.MinMinutes == 0 &&
+		f.MaxMinutes == 0 &&
+		f.Since.IsZero() &&
+		f.Before.IsZero() &&
+		len(f.Tags) == 0 &&
+		!f.Untagged &&
+		f.IDs == nil
+}
+
+// Match tells if the page passes the filter, it's for backends that don't filter in a query.
+// now is the time snoozes are compared with.
+func (f Filter) Match(p *Page, now time.Time) bool {
+	switch {
+	case f.Domain != "" && p.Domain != NormalizeDomain(f.Domain):
+		return false
+	case f.Unread && !p.ReadAt.IsZero():
+		return false
+	case f.Pinned && !p.Pinned:
+		return false
+	case f.Broken && !p.Broken:
+		return false
+	case f.Untagged && len(p.Tags) > 0:
+		return false
+	case f.Kind != KindUnknown && p.Kind != f.Kind:
+		return false
+	case (f.MinMinutes > 0 || f.MaxMinutes > 0) && p.ReadMinutes <= 0:
+		return false
+	case f.MinMinutes > 0 && p.ReadMinutes < f.MinMinutes:
+		return false
+	case f.MaxMinutes > 0 && p.ReadMinutes > f.MaxMinutes:
+		return false
+	case f.Awake && p.SnoozedUntil.After(now):
+		return false
+	case f.Free && p.ClaimedUntil.After(now):
+		return false
+	case !f.Since.IsZero() && p.CreatedAt.Before(f.Since):
+		return false
+	case !f.Before.IsZero() && !p.CreatedAt.Before(f.Before):
+		return false
+	case f.IDs != nil && !slices.Contains(f.IDs, p.ID):
+		return false
+	}
+
+	for _, tag := range f.Tags {
+		if !slices.Contains(p.Tags, strings.ToLower(tag)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Sort puts pages in the order of the filter, see Order.
+func (f Filter) Sort(pages []Page) {
+	sort.SliceStable(pages, func(i, j int) bool {
+		a, b := &pages[i], &pages[j]
+
+		switch f.Order {
+		case OrderOldest:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		case OrderNewest:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID > b.ID
+		default:
+			return a.ID < b.ID
+		}
+	})
+}
+
+// DomainOf returns normalized host of the page url, without "www." prefix.
+func DomainOf(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	return NormalizeDomain(u.Hostname())
+}
+
+func NormalizeDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(domain), "www.")
+}
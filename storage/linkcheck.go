@@ -0,0 +1,10 @@
+package storage
+
+import "time"
+
+// LinkStatus is the result of the last check of a saved link, see the linkcheck package.
+type LinkStatus struct {
+	Code      int  // HTTP status, 0 if there was no response
+	Broken    bool // the page is gone for good: 404, 410 or the host doesn't exist
+	CheckedAt time.Time
+}
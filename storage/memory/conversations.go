@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"narasla_bot/lib/e"
+	"narasla_bot/storage"
+	"slices"
+	"time"
+)
+
+// SaveMessageRef remembers which page a bot message in the chat is about.
+func (s *Storage) SaveMessageRef(_ context.Context, chatID, messageID, pageID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refs := slices.DeleteFunc(slices.Clone(s.refs), func(r MessageRef) bool {
+		return r.ChatID == chatID && r.MessageID == messageID
+	})
+	refs = append(refs, MessageRef{ChatID: chatID, MessageID: messageID, PageID: pageID})
+
+	return e.Wrap("Storage: can't save message ref", s.setRefs(refs))
+}
+
+func (s *Storage) PageIDByMessage(_ context.Context, chatID, messageID int64) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.refs {
+		if r.ChatID != chatID || r.MessageID != messageID {
+			continue
+		}
+		if _, ok := s.pageOwner[r.PageID]; !ok {
+			break
+		}
+		return r.PageID, nil
+	}
+
+	return 0, storage.ErrNotFound
+}
+
+// SetConversation starts or moves on the conversation of the user in the chat,
+// there is only one at a time.
+func (s *Storage) SetConversation(_ context.Context, c *storage.Conversation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convs := slices.DeleteFunc(slices.Clone(s.convs), func(old storage.Conversation) bool {
+		return old.ChatID == c.ChatID && old.UserID == c.UserID
+	})
+	convs = append(convs, *c)
+
+	return e.Wrap("Storage: can't set conversation", s.setConversations(convs))
+}
+
+// GetConversation returns storage.ErrNoConversation if there is none or it has expired.
+func (s *Storage) GetConversation(_ context.Context, chatID, userID int64) (*storage.Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	for _, c := range s.convs {
+		if c.ChatID == chatID && c.UserID == userID && c.ExpiresAt.After(now) {
+			return &c, nil
+		}
+	}
+
+	return nil, storage.ErrNoConversation
+}
+
+func (s *Storage) DeleteConversation(_ context.Context, chatID, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convs := slices.DeleteFunc(slices.Clone(s.convs), func(c storage.Conversation) bool {
+		return c.ChatID == chatID && c.UserID == userID
+	})
+	if len(convs) == len(s.convs) {
+		return nil
+	}
+
+	return e.Wrap("Storage: can't delete conversation", s.setConversations(convs))
+}
+
+// Prune drops expired conversations, refs to pages removed for good and their snapshots.
+// Backends that load the storage from disk call it once on start.
+func (s *Storage) Prune(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	convs := slices.DeleteFunc(slices.Clone(s.convs), func(c storage.Conversation) bool {
+		return !c.ExpiresAt.After(now)
+	})
+	if len(convs) != len(s.convs) {
+		if err := s.setConversations(convs); err != nil {
+			return err
+		}
+	}
+
+	refs := slices.DeleteFunc(slices.Clone(s.refs), func(r MessageRef) bool {
+		_, ok := s.pageOwner[r.PageID]
+		return !ok
+	})
+	if len(refs) != len(s.refs) {
+		if err := s.setRefs(refs); err != nil {
+			return err
+		}
+	}
+
+	return s.pruneSnapshots()
+}
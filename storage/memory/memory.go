@@ -0,0 +1,252 @@
+package memory
+
+import (
+	"cmp"
+	"narasla_bot/storage"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Storage keeps all lists in memory, it's for tests and runs that don't need the data later.
+// Other backends can reuse it with a Persister that writes every change somewhere,
+// see the files package.
+type Storage struct {
+	mu        sync.RWMutex
+	p         Persister
+	meta      Meta
+	users     map[int64]*User
+	owners    map[int64]*Owner
+	pageOwner map[int64]int64 // page id -> owner id
+	convs     []storage.Conversation
+	refs      []MessageRef
+}
+
+// undoJournalSize is how many removals per owner can be undone, a bulk delete is one.
+const undoJournalSize = 20
+
+// Meta holds the id counters.
+type Meta struct {
+	NextPageID  int64
+	NextEntryID int64
+}
+
+type User struct {
+	storage.User
+	BrokenReportAt time.Time
+}
+
+// Record is a page with the state only backends need.
+type Record struct {
+	storage.Page
+	EnrichedAt time.Time
+	Link       storage.LinkStatus
+}
+
+// Entry is a removed page in the undo journal.
+type Entry struct {
+	ID     int64
+	Op     int64 // id of the first entry of the removal, Undo restores them together
+	Record Record
+}
+
+type Owner struct {
+	Pages   []Record // ordered by id
+	Journal []Entry  // oldest first
+}
+
+type MessageRef struct {
+	ChatID    int64
+	MessageID int64
+	PageID    int64
+}
+
+// State is everything the storage holds except snapshots, a Persister loads it for NewWith.
+type State struct {
+	Meta          Meta
+	Users         []*User
+	Owners        map[int64]*Owner
+	Conversations []storage.Conversation
+	Refs          []MessageRef
+}
+
+// Persister gets every change before the storage applies it, an error cancels the change.
+// Each method gets the whole new value of its part, e.g. all pages of the owner.
+// Snapshots are too big to keep in memory, so the persister stores and reads them itself.
+type Persister interface {
+	SaveMeta(m Meta) error
+	SaveUsers(users []*User) error
+	SavePages(ownerID int64, pages []Record) error
+	SaveJournal(ownerID int64, journal []Entry) error
+	SaveConversations(convs []storage.Conversation) error
+	SaveRefs(refs []MessageRef) error
+
+	SaveSnapshot(snap *storage.Snapshot) error
+	LoadSnapshot(pageID int64) (*storage.Snapshot, error) // storage.ErrNoSnapshot if there is none
+	PruneSnapshots(keep func(pageID int64) bool) error
+}
+
+// New returns an empty storage that keeps nothing after the process exits.
+func New() *Storage {
+	return NewWith(State{}, &volatile{snapshots: make(map[int64]storage.Snapshot)})
+}
+
+// NewWith returns a storage with the loaded state that passes every change to p.
+func NewWith(state State, p Persister) *Storage {
+	s := &Storage{
+		p:         p,
+		meta:      state.Meta,
+		users:     make(map[int64]*User, len(state.Users)),
+		owners:    state.Owners,
+		pageOwner: make(map[int64]int64),
+		convs:     state.Conversations,
+		refs:      state.Refs,
+	}
+
+	if s.owners == nil {
+		s.owners = make(map[int64]*Owner)
+	}
+
+	for _, u := range state.Users {
+		s.users[u.OwnerID] = u
+	}
+
+	for ownerID, o := range s.owners {
+		for _, r := range o.Pages {
+			s.pageOwner[r.ID] = ownerID
+		}
+
+		// entries written before operations were each one removal.
+		for i := range o.Journal {
+			if o.Journal[i].Op == 0 {
+				o.Journal[i].Op = o.Journal[i].ID
+			}
+		}
+	}
+
+	return s
+}
+
+// owner returns pages of the owner, creating an empty list for a new one.
+func (s *Storage) owner(ownerID int64) *Owner {
+	o, ok := s.owners[ownerID]
+	if !ok {
+		o = &Owner{}
+		s.owners[ownerID] = o
+	}
+
+	return o
+}
+
+func (s *Storage) setMeta(m Meta) error {
+	if err := s.p.SaveMeta(m); err != nil {
+		return err
+	}
+	s.meta = m
+
+	return nil
+}
+
+// setPages saves the pages of the owner and then replaces them in memory,
+// so a failed write doesn't change what the storage returns.
+func (s *Storage) setPages(ownerID int64, pages []Record) error {
+	if err := s.p.SavePages(ownerID, pages); err != nil {
+		return err
+	}
+	s.owner(ownerID).Pages = pages
+
+	return nil
+}
+
+func (s *Storage) setJournal(ownerID int64, journal []Entry) error {
+	if err := s.p.SaveJournal(ownerID, journal); err != nil {
+		return err
+	}
+	s.owner(ownerID).Journal = journal
+
+	return nil
+}
+
+// setUser saves all users with u in them and then puts u in memory.
+func (s *Storage) setUser(u *User) error {
+	users := make([]*User, 0, len(s.users)+1)
+	for ownerID, old := range s.users {
+		if ownerID != u.OwnerID {
+			users = append(users, old)
+		}
+	}
+	users = append(users, u)
+	slices.SortFunc(users, func(a, b *User) int { return cmp.Compare(a.OwnerID, b.OwnerID) })
+
+	if err := s.p.SaveUsers(users); err != nil {
+		return err
+	}
+	s.users[u.OwnerID] = u
+
+	return nil
+}
+
+func (s *Storage) setConversations(convs []storage.Conversation) error {
+	if err := s.p.SaveConversations(convs); err != nil {
+		return err
+	}
+	s.convs = convs
+
+	return nil
+}
+
+func (s *Storage) setRefs(refs []MessageRef) error {
+	if err := s.p.SaveRefs(refs); err != nil {
+		return err
+	}
+	s.refs = refs
+
+	return nil
+}
+
+// volatile is the Persister of New, it keeps only snapshots.
+type volatile struct {
+	mu        sync.Mutex
+	snapshots map[int64]storage.Snapshot
+}
+
+func (v *volatile) SaveMeta(Meta) error                            { return nil }
+func (v *volatile) SaveUsers([]*User) error                        { return nil }
+func (v *volatile) SavePages(int64, []Record) error                { return nil }
+func (v *volatile) SaveJournal(int64, []Entry) error               { return nil }
+func (v *volatile) SaveConversations([]storage.Conversation) error { return nil }
+func (v *volatile) SaveRefs([]MessageRef) error                    { return nil }
+
+func (v *volatile) SaveSnapshot(snap *storage.Snapshot) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.snapshots[snap.PageID] = *snap
+
+	return nil
+}
+
+func (v *volatile) LoadSnapshot(pageID int64) (*storage.Snapshot, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	snap, ok := v.snapshots[pageID]
+	if !ok {
+		return nil, storage.ErrNoSnapshot
+	}
+
+	return &snap, nil
+}
+
+func (v *volatile) PruneSnapshots(keep func(pageID int64) bool) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for pageID := range v.snapshots {
+		if !keep(pageID) {
+			delete(v.snapshots, pageID)
+		}
+	}
+
+	return nil
+}
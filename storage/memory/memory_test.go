@@ -0,0 +1,19 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"narasla_bot/storage"
+	"narasla_bot/storage/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	open := func() (storage.Storage, func() error, error) {
+		return New(), func() error { return nil }, nil
+	}
+
+	if err := storagetest.Run(context.Background(), open); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,613 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"narasla_bot/lib/e"
+	"narasla_bot/storage"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Save stores the page and sets page.ID, storage.ErrAlreadyExists if the owner has the url.
+// A set page.CreatedAt is kept, the save date is now otherwise.
+func (s *Storage) Save(_ context.Context, page *storage.Page) (err error) {
+	defer func() { err = e.Wrap("Storage: can't save page", err) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.owner(page.OwnerID)
+	if o.indexByURL(page.URL) >= 0 {
+		return storage.ErrAlreadyExists
+	}
+
+	m := s.meta
+	m.NextPageID++
+	if err := s.setMeta(m); err != nil {
+		return err
+	}
+
+	r := Record{Page: *page}
+	r.ID = m.NextPageID
+	r.Domain = storage.DomainOf(page.URL)
+	r.Tags = lowerTags(page.Tags)
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	r.CreatedAt = r.CreatedAt.UTC().Truncate(time.Second)
+	r.Content = storage.Content{}
+	r.Broken = false
+	r.SnoozedUntil = time.Time{}
+	r.ReadAt = time.Time{}
+
+	// ids only grow, so the index stays sorted.
+	if err := s.setPages(page.OwnerID, append(slices.Clone(o.Pages), r)); err != nil {
+		return err
+	}
+
+	page.ID = r.ID
+	s.pageOwner[r.ID] = page.OwnerID
+
+	return nil
+}
+
+// PickNth returns the n-th page (from 0) matched by the filter in the filter order,
+// storage.ErrNoSavedPages if there are fewer pages.
+func (s *Storage) PickNth(_ context.Context, ownerID int64, f storage.Filter, n int) (*storage.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pages := s.match(ownerID, f)
+	if n < 0 || n >= len(pages) {
+		return nil, storage.ErrNoSavedPages
+	}
+
+	return &pages[n], nil
+}
+
+// Domains returns distinct domains of pages matched by the filter, sorted.
+func (s *Storage) Domains(_ context.Context, ownerID int64, f storage.Filter) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var domains []string
+	for _, p := range s.match(ownerID, f) {
+		domains = append(domains, p.Domain)
+	}
+	slices.Sort(domains)
+
+	return slices.Compact(domains), nil
+}
+
+// Tags returns distinct tags of pages matched by the filter, sorted.
+func (s *Storage) Tags(_ context.Context, ownerID int64, f storage.Filter) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tags []string
+	for _, p := range s.match(ownerID, f) {
+		tags = append(tags, p.Tags...)
+	}
+	slices.Sort(tags)
+
+	return slices.Compact(tags), nil
+}
+
+func (s *Storage) List(_ context.Context, ownerID int64, username string, f storage.Filter, limit, offset int) ([]storage.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pages := s.match(ownerID, f)
+	if offset >= len(pages) {
+		return []storage.Page{}, nil
+	}
+	pages = pages[offset:]
+	if limit >= 0 && limit < len(pages) {
+		pages = pages[:limit]
+	}
+
+	for i := range pages {
+		pages[i].UserName = username
+	}
+
+	return pages, nil
+}
+
+func (s *Storage) Count(_ context.Context, ownerID int64, f storage.Filter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.match(ownerID, f)), nil
+}
+
+// IsExists checks if page exists in storage.
+func (s *Storage) IsExists(_ context.Context, ownerID int64, url string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	o, ok := s.owners[ownerID]
+
+	return ok && o.indexByURL(url) >= 0, nil
+}
+
+// Remove deletes the page and keeps a copy in the owner's undo journal.
+func (s *Storage) Remove(_ context.Context, page *storage.Page) (err error) {
+	defer func() { err = e.Wrap("Storage: can't remove page", err) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.removeWhere(page.OwnerID, func(r *Record) bool { return r.ID == page.ID })
+
+	return err
+}
+
+// RemoveByURL works like Remove and returns id of the removed page, so it can be restored by Undo.
+func (s *Storage) RemoveByURL(_ context.Context, ownerID int64, url string) (pageID int64, err error) {
+	defer func() { err = e.Wrap("Storage: can't remove page", err) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed, err := s.removeWhere(ownerID, func(r *Record) bool { return r.URL == url })
+	if err != nil {
+		return 0, err
+	}
+
+	return removed[0].ID, nil
+}
+
+// RemoveFiltered removes every page matching the filter and returns how many were removed.
+// Removed pages go to the undo journal.
+func (s *Storage) RemoveFiltered(_ context.Context, ownerID int64, f storage.Filter) (removed int, err error) {
+	defer func() { err = e.Wrap("Storage: can't remove pages", err) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	pages, err := s.removeWhere(ownerID, func(r *Record) bool { return f.Match(&r.Page, now) })
+	if errors.Is(err, storage.ErrNotFound) {
+		return 0, nil
+	}
+
+	return len(pages), err
+}
+
+// removeWhere moves pages of the owner matched by fn to the undo journal,
+// storage.ErrNotFound if there are none. The journal is written first:
+// after a crash between the writes the page is both saved and restorable, but never lost.
+func (s *Storage) removeWhere(ownerID int64, fn func(r *Record) bool) ([]Record, error) {
+	o := s.owner(ownerID)
+
+	var kept, removed []Record
+	for i := range o.Pages {
+		if fn(&o.Pages[i]) {
+			removed = append(removed, o.Pages[i])
+		} else {
+			kept = append(kept, o.Pages[i])
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	m := s.meta
+	op := m.NextEntryID + 1
+	journal := slices.Clone(o.Journal)
+	for _, r := range removed {
+		m.NextEntryID++
+		journal = append(journal, Entry{ID: m.NextEntryID, Op: op, Record: r})
+	}
+	journal = trimJournal(journal)
+
+	if err := s.setMeta(m); err != nil {
+		return nil, err
+	}
+
+	if err := s.setJournal(ownerID, journal); err != nil {
+		return nil, err
+	}
+	if err := s.setPages(ownerID, kept); err != nil {
+		return nil, err
+	}
+
+	for _, r := range removed {
+		delete(s.pageOwner, r.ID)
+	}
+
+	return removed, nil
+}
+
+// trimJournal keeps entries of the last undoJournalSize removals.
+func trimJournal(journal []Entry) []Entry {
+	ops := 0
+	for i := len(journal) - 1; i >= 0; i-- {
+		if i == len(journal)-1 || journal[i].Op != journal[i+1].Op {
+			ops++
+		}
+		if ops > undoJournalSize {
+			return journal[i+1:]
+		}
+	}
+
+	return journal
+}
+
+// Undo restores removed pages with their original ids and metadata: the page with pageID,
+// or with pageID 0 all pages of the owner's last removal, e.g. every page of one /del 1-5.
+// Pages whose url was saved again are skipped, storage.ErrAlreadyExists if that's all of them.
+// Pages come back without snooze and link check results.
+func (s *Storage) Undo(_ context.Context, ownerID, pageID int64) (restored []storage.Page, err error) {
+	defer func() {
+		if !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrAlreadyExists) {
+			err = e.Wrap("Storage: can't undo", err)
+		}
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.owner(ownerID)
+	if len(o.Journal) == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	match := func(en Entry) bool { return en.Record.ID == pageID }
+	if pageID == 0 {
+		op := o.Journal[len(o.Journal)-1].Op
+		match = func(en Entry) bool { return en.Op == op }
+	}
+
+	var journal []Entry
+	pages := slices.Clone(o.Pages)
+	found := false
+
+	for _, en := range o.Journal {
+		if !match(en) {
+			journal = append(journal, en)
+			continue
+		}
+		found = true
+
+		r := en.Record
+		// the same url was saved again after removal.
+		if o.indexByURL(r.URL) >= 0 {
+			continue
+		}
+
+		r.SnoozedUntil = time.Time{}
+		r.ClaimedUntil = time.Time{}
+		r.Broken = false
+		r.Link = storage.LinkStatus{}
+
+		at, _ := slices.BinarySearchFunc(pages, r.ID, func(p Record, id int64) int { return cmp.Compare(p.ID, id) })
+		pages = slices.Insert(pages, at, r)
+		restored = append(restored, copyPage(r.Page))
+	}
+	if !found {
+		return nil, storage.ErrNotFound
+	}
+
+	// pages are written before the entries are dropped, the other order could lose them in a crash.
+	if len(restored) > 0 {
+		if err := s.setPages(ownerID, pages); err != nil {
+			return nil, err
+		}
+		for _, p := range restored {
+			s.pageOwner[p.ID] = ownerID
+		}
+	}
+
+	if err := s.setJournal(ownerID, journal); err != nil {
+		return nil, err
+	}
+
+	if len(restored) == 0 {
+		return nil, storage.ErrAlreadyExists
+	}
+
+	return restored, nil
+}
+
+func (s *Storage) SetNote(_ context.Context, ownerID, pageID int64, note string) error {
+	return s.update(ownerID, pageID, func(r *Record) { r.Note = note })
+}
+
+// SetPinned marks the page as high priority, pinned pages are delivered first.
+func (s *Storage) SetPinned(_ context.Context, ownerID, pageID int64, pinned bool) error {
+	return s.update(ownerID, pageID, func(r *Record) { r.Pinned = pinned })
+}
+
+// MarkRead keeps the delivered page in the list, but unread filters skip it.
+func (s *Storage) MarkRead(_ context.Context, page *storage.Page) error {
+	err := s.update(page.OwnerID, page.ID, func(r *Record) { r.ReadAt = time.Now().UTC().Truncate(time.Second) })
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// Claim holds the page for a delivery until the time, so other picks skip it (Filter.Free).
+// storage.ErrClaimed if another delivery holds it or the page is gone.
+func (s *Storage) Claim(_ context.Context, ownerID, pageID int64, until time.Time) error {
+	claimed := false
+	err := s.update(ownerID, pageID, func(r *Record) {
+		if r.ClaimedUntil.After(time.Now()) {
+			claimed = true
+			return
+		}
+		r.ClaimedUntil = until.Truncate(time.Second)
+	})
+	if errors.Is(err, storage.ErrNotFound) || claimed {
+		return storage.ErrClaimed
+	}
+
+	return err
+}
+
+// Ack finishes the delivery of the claimed page: removes it to the undo journal,
+// or with archive marks it read and frees it. A page removed during the delivery is fine.
+func (s *Storage) Ack(ctx context.Context, page *storage.Page, archive bool) error {
+	var err error
+	if archive {
+		err = s.update(page.OwnerID, page.ID, func(r *Record) {
+			r.ReadAt = time.Now().UTC().Truncate(time.Second)
+			r.ClaimedUntil = time.Time{}
+		})
+	} else {
+		err = s.Remove(ctx, page)
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// Release frees the claimed page when the delivery failed, the next pick can take it again.
+func (s *Storage) Release(_ context.Context, page *storage.Page) error {
+	err := s.update(page.OwnerID, page.ID, func(r *Record) { r.ClaimedUntil = time.Time{} })
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// Snooze hides the page from random picks until the time, then ListDueSnoozed returns it for a reminder.
+// Snoozed page becomes unread again.
+func (s *Storage) Snooze(_ context.Context, ownerID, pageID int64, until time.Time) error {
+	return s.update(ownerID, pageID, func(r *Record) {
+		r.SnoozedUntil = until.Truncate(time.Second)
+		r.ReadAt = time.Time{}
+	})
+}
+
+// ClearSnooze is called after the reminder about the page is sent.
+func (s *Storage) ClearSnooze(_ context.Context, page *storage.Page) error {
+	err := s.update(page.OwnerID, page.ID, func(r *Record) { r.SnoozedUntil = time.Time{} })
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// ListDueSnoozed returns pages of all owners whose snooze is over, the earliest first.
+func (s *Storage) ListDueSnoozed(_ context.Context, now time.Time, limit int) ([]storage.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := s.collect(func(r *Record) bool { return !r.SnoozedUntil.IsZero() && !r.SnoozedUntil.After(now) })
+	slices.SortFunc(list, func(a, b Record) int {
+		if c := a.SnoozedUntil.Compare(b.SnoozedUntil); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return pagesOf(list, limit), nil
+}
+
+// ListUnenriched returns pages of all owners whose content hasn't been detected yet, the oldest first.
+func (s *Storage) ListUnenriched(_ context.Context, limit int) ([]storage.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := s.collect(func(r *Record) bool { return r.EnrichedAt.IsZero() })
+	slices.SortFunc(list, func(a, b Record) int { return cmp.Compare(a.ID, b.ID) })
+
+	return pagesOf(list, limit), nil
+}
+
+// SetContent stores the detected content of the page, the page isn't listed as unenriched after it.
+func (s *Storage) SetContent(_ context.Context, pageID int64, c storage.Content) error {
+	err := s.updateByID(pageID, func(r *Record) {
+		r.Content = c
+		r.EnrichedAt = time.Now().UTC().Truncate(time.Second)
+	})
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// ListUnchecked returns pages of all owners not checked since the time, never checked first.
+func (s *Storage) ListUnchecked(_ context.Context, checkedBefore time.Time, limit int) ([]storage.Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := s.collect(func(r *Record) bool { return r.Link.CheckedAt.IsZero() || r.Link.CheckedAt.Before(checkedBefore) })
+	slices.SortFunc(list, func(a, b Record) int {
+		if c := a.Link.CheckedAt.Compare(b.Link.CheckedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return pagesOf(list, limit), nil
+}
+
+func (s *Storage) SetLinkStatus(_ context.Context, pageID int64, ls storage.LinkStatus) error {
+	err := s.updateByID(pageID, func(r *Record) {
+		r.Link = ls
+		r.Link.CheckedAt = ls.CheckedAt.UTC().Truncate(time.Second)
+		r.Broken = ls.Broken
+	})
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// ListBrokenOwners returns owners with broken pages who weren't told about them since the time.
+func (s *Storage) ListBrokenOwners(_ context.Context, reportedBefore time.Time, limit int) ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var owners []int64
+	for ownerID, o := range s.owners {
+		u, ok := s.users[ownerID]
+		if !ok || !u.BrokenReportAt.IsZero() && !u.BrokenReportAt.Before(reportedBefore) {
+			continue
+		}
+		if slices.ContainsFunc(o.Pages, func(r Record) bool { return r.Broken }) {
+			owners = append(owners, ownerID)
+		}
+	}
+	slices.Sort(owners)
+
+	if limit >= 0 && limit < len(owners) {
+		owners = owners[:limit]
+	}
+
+	return owners, nil
+}
+
+// match returns copies of the owner's pages passing the filter, in the filter order.
+func (s *Storage) match(ownerID int64, f storage.Filter) []storage.Page {
+	o, ok := s.owners[ownerID]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+
+	var pages []storage.Page
+	for i := range o.Pages {
+		if f.Match(&o.Pages[i].Page, now) {
+			pages = append(pages, copyPage(o.Pages[i].Page))
+		}
+	}
+	f.Sort(pages)
+
+	return pages
+}
+
+// collect returns pages of all owners for which fn is true.
+func (s *Storage) collect(fn func(r *Record) bool) []Record {
+	var list []Record
+	for _, o := range s.owners {
+		for i := range o.Pages {
+			if fn(&o.Pages[i]) {
+				list = append(list, o.Pages[i])
+			}
+		}
+	}
+
+	return list
+}
+
+// update changes the page of the owner with fn and writes the index, storage.ErrNotFound if there is no page.
+func (s *Storage) update(ownerID, pageID int64, fn func(r *Record)) (err error) {
+	defer func() {
+		if !errors.Is(err, storage.ErrNotFound) {
+			err = e.Wrap("Storage: can't update page", err)
+		}
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.owners[ownerID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	i := o.indexByID(pageID)
+	if i < 0 {
+		return storage.ErrNotFound
+	}
+
+	pages := slices.Clone(o.Pages)
+	fn(&pages[i])
+
+	return s.setPages(ownerID, pages)
+}
+
+// updateByID is update for callers that know only the page id.
+func (s *Storage) updateByID(pageID int64, fn func(r *Record)) error {
+	s.mu.RLock()
+	ownerID, ok := s.pageOwner[pageID]
+	s.mu.RUnlock()
+
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	return s.update(ownerID, pageID, fn)
+}
+
+func (o *Owner) indexByID(pageID int64) int {
+	i, found := slices.BinarySearchFunc(o.Pages, pageID, func(r Record, id int64) int { return cmp.Compare(r.ID, id) })
+	if !found {
+		return -1
+	}
+
+	return i
+}
+
+func (o *Owner) indexByURL(url string) int {
+	return slices.IndexFunc(o.Pages, func(r Record) bool { return r.URL == url })
+}
+
+func pagesOf(list []Record, limit int) []storage.Page {
+	if limit >= 0 && limit < len(list) {
+		list = list[:limit]
+	}
+
+	pages := make([]storage.Page, 0, len(list))
+	for _, r := range list {
+		pages = append(pages, copyPage(r.Page))
+	}
+
+	return pages
+}
+
+// copyPage returns the page with its own tags, callers can't change the stored ones.
+func copyPage(p storage.Page) storage.Page {
+	p.Tags = slices.Clone(p.Tags)
+
+	return p
+}
+
+func lowerTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	lower := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		lower = append(lower, strings.ToLower(tag))
+	}
+
+	return lower
+}
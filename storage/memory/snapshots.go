@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"narasla_bot/lib/e"
+	"narasla_bot/storage"
+	"time"
+)
+
+// SaveSnapshot stores the snapshot of the page. A new snapshot replaces the old one.
+func (s *Storage) SaveSnapshot(_ context.Context, snap *storage.Snapshot) error {
+	copied := *snap
+	if copied.CreatedAt.IsZero() {
+		copied.CreatedAt = time.Now()
+	}
+	copied.CreatedAt = copied.CreatedAt.UTC().Truncate(time.Second)
+
+	return e.Wrap("Storage: can't save snapshot", s.p.SaveSnapshot(&copied))
+}
+
+// Snapshot returns the snapshot of the owner's page, storage.ErrNoSnapshot if there is none.
+func (s *Storage) Snapshot(_ context.Context, ownerID, pageID int64) (*storage.Snapshot, error) {
+	s.mu.RLock()
+	pageOwner, ok := s.pageOwner[pageID]
+	s.mu.RUnlock()
+
+	if !ok || pageOwner != ownerID {
+		return nil, storage.ErrNoSnapshot
+	}
+
+	snap, err := s.p.LoadSnapshot(pageID)
+	if errors.Is(err, storage.ErrNoSnapshot) {
+		return nil, storage.ErrNoSnapshot
+	}
+	if err != nil {
+		return nil, e.Wrap("Storage: can't get snapshot", err)
+	}
+
+	return snap, nil
+}
+
+// pruneSnapshots removes snapshots of pages removed for good, also out of the undo journal.
+func (s *Storage) pruneSnapshots() error {
+	keep := make(map[int64]bool, len(s.pageOwner))
+	for pageID := range s.pageOwner {
+		keep[pageID] = true
+	}
+	for _, o := range s.owners {
+		for _, en := range o.Journal {
+			keep[en.Record.ID] = true
+		}
+	}
+
+	return s.p.PruneSnapshots(func(pageID int64) bool { return keep[pageID] })
+}
@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"narasla_bot/lib/e"
+	"narasla_bot/storage"
+	"slices"
+	"time"
+)
+
+// defaultSendHour is the autopush hour of a new user before the first delivery picks one in the window.
+const defaultSendHour = 12
+
+func (s *Storage) ListEnabledUsers(_ context.Context) ([]storage.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	enabledUsers := make([]storage.User, 0, len(s.users))
+	for _, u := range s.users {
+		if !u.Autopush || u.ChatID == 0 {
+			continue
+		}
+		if u.Kind != storage.OwnerUser && !u.Shared {
+			continue
+		}
+		enabledUsers = append(enabledUsers, u.User)
+	}
+	slices.SortFunc(enabledUsers, func(a, b storage.User) int { return cmp.Compare(a.OwnerID, b.OwnerID) })
+
+	return enabledUsers, nil
+}
+
+func (s *Storage) UpdateLastSendAt(_ context.Context, ownerID, newTime int64, newHour, newMinute int) error {
+	err := s.updateUser(ownerID, func(u *User) {
+		u.LastSendAt = sql.NullInt64{Int64: newTime, Valid: true}
+		u.SendHour = newHour
+		u.SendMinute = newMinute
+	})
+
+	return e.Wrap("Storage: can't update last send at for user", ignoreNotFound(err))
+}
+
+// UpdateUserInfo creates the user or updates their chat and username.
+// lang is saved only if the user has no language yet.
+func (s *Storage) UpdateUserInfo(_ context.Context, ownerID, chatID int64, username, lang string) (err error) {
+	defer func() { err = e.Wrap("Storage: can't update user info", err) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[ownerID]
+	if !ok {
+		u = &User{User: storage.User{
+			OwnerID:    ownerID,
+			Kind:       storage.OwnerUser,
+			Settings:   storage.DefaultSettings(),
+			SendHour:   defaultSendHour,
+			LastSendAt: sql.NullInt64{Int64: time.Now().Unix(), Valid: true},
+		}}
+	} else {
+		copied := *u
+		u = &copied
+	}
+
+	u.ChatID = chatID
+	u.Username = username
+	if u.Lang == "" {
+		u.Lang = lang
+	}
+
+	return s.setUser(u)
+}
+
+// UpdateChatInfo registers a group chat as a list owner, autopush is off for new chats.
+func (s *Storage) UpdateChatInfo(_ context.Context, chatID int64, title string) (err error) {
+	defer func() { err = e.Wrap("Storage: can't update chat info", err) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[chatID]
+	if !ok {
+		u = &User{User: storage.User{
+			OwnerID:    chatID,
+			ChatID:     chatID,
+			Kind:       storage.OwnerChat,
+			Settings:   storage.DefaultSettings(),
+			SendHour:   defaultSendHour,
+			LastSendAt: sql.NullInt64{Int64: time.Now().Unix(), Valid: true},
+		}}
+		u.Autopush = false
+	} else {
+		copied := *u
+		u = &copied
+	}
+
+	u.Username = title
+
+	return s.setUser(u)
+}
+
+func (s *Storage) SwitchShared(_ context.Context, chatID int64, shared bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[chatID]
+	if !ok || u.Kind != storage.OwnerChat {
+		return storage.ErrUserNotFound
+	}
+
+	copied := *u
+	copied.Shared = shared
+
+	return e.Wrap("Storage: can't change shared for chat", s.setUser(&copied))
+}
+
+// UpdateSettings saves all settings at once, see storage.Settings.
+func (s *Storage) UpdateSettings(_ context.Context, ownerID int64, set storage.Settings) error {
+	err := s.updateUser(ownerID, func(u *User) { u.Settings = set })
+	if err == storage.ErrUserNotFound {
+		return err
+	}
+
+	return e.Wrap("Storage: can't update settings", err)
+}
+
+// SetPickCursor remembers where a stateful pick strategy stopped, see selector.RoundRobin.
+func (s *Storage) SetPickCursor(_ context.Context, ownerID int64, cursor string) error {
+	err := s.updateUser(ownerID, func(u *User) { u.PickCursor = cursor })
+
+	return e.Wrap("Storage: can't update pick cursor", ignoreNotFound(err))
+}
+
+func (s *Storage) SetBrokenReportAt(_ context.Context, ownerID int64, at time.Time) error {
+	err := s.updateUser(ownerID, func(u *User) { u.BrokenReportAt = at.UTC().Truncate(time.Second) })
+
+	return e.Wrap("Storage: can't update broken report time", ignoreNotFound(err))
+}
+
+// ListUsers returns every user and group chat, ordered by owner id.
+func (s *Storage) ListUsers(_ context.Context) ([]storage.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]storage.User, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, u.User)
+	}
+	slices.SortFunc(users, func(a, b storage.User) int { return cmp.Compare(a.OwnerID, b.OwnerID) })
+
+	return users, nil
+}
+
+func (s *Storage) GetUserInfo(_ context.Context, ownerID int64) (*storage.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[ownerID]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	copied := u.User
+
+	return &copied, nil
+}
+
+// updateUser changes a copy of the user with fn and stores it, storage.ErrUserNotFound if there is no user.
+func (s *Storage) updateUser(ownerID int64, fn func(u *User)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[ownerID]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+
+	copied := *u
+	fn(&copied)
+
+	return s.setUser(&copied)
+}
+
+// ignoreNotFound drops storage.ErrUserNotFound for updates that don't report a missing user.
+func ignoreNotFound(err error) error {
+	if err == storage.ErrUserNotFound {
+		return nil
+	}
+
+	return err
+}
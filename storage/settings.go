@@ -0,0 +1,42 @@
+package storage
+
+import "time"
+
+// Settings are what the owner of a list changes with /settings.
+type Settings struct {
+	Autopush    bool   // daily delivery of one page
+	Timezone    string // IANA name, e.g. "Asia/Almaty"
+	WindowStart int    // autopush sends between WindowStart:00 and WindowEnd:00 local time
+	WindowEnd   int
+	Archive     bool   // delivered pages are marked read instead of being deleted
+	Lang        string // empty until known
+	Strategy    string // how autopush and /rnd pick a page, see selector.Strategy
+}
+
+const (
+	DefaultWindowStart = 9
+	DefaultWindowEnd   = 24
+	DefaultTimezone    = "Asia/Almaty"
+	DefaultStrategy    = "random"
+)
+
+// DefaultSettings are the settings of a new user, group chats start with autopush off.
+func DefaultSettings() Settings {
+	return Settings{
+		Autopush:    true,
+		Timezone:    DefaultTimezone,
+		WindowStart: DefaultWindowStart,
+		WindowEnd:   DefaultWindowEnd,
+		Strategy:    DefaultStrategy,
+	}
+}
+
+// Location of the owner, UTC if the timezone is unknown.
+func (s Settings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
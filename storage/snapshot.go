@@ -0,0 +1,13 @@
+package storage
+
+import "time"
+
+// Snapshot is a readable copy of a saved page: its title and the main text
+// split into paragraphs by blank lines. It's kept while the page is in the list
+// or can be restored with /undo.
+type Snapshot struct {
+	PageID    int64
+	Title     string
+	Text      string
+	CreatedAt time.Time
+}
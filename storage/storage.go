@@ -12,37 +12,88 @@ import (
 )
 
 // TODO: implement new fields
+//
+// ownerID everywhere is the owner of the list: a user id for personal lists,
+// or a group chat id for shared group lists (see OwnerKind).
 type Storage interface {
 	Save(ctx context.Context, p *Page) error
-	PickRandom(ctx context.Context, ownerID int64) (*Page, error)
+	PickNth(ctx context.Context, ownerID int64, f Filter, n int) (*Page, error)
+	Domains(ctx context.Context, ownerID int64, f Filter) ([]string, error)
+	Tags(ctx context.Context, ownerID int64, f Filter) ([]string, error)
 	Remove(ctx context.Context, p *Page) error
-	RemoveByURL(ctx context.Context, ownerID int64, url string) error
-	List(ctx context.Context, ownerID int64, username string, limit, offset int) ([]Page, error)
-	Count(ctx context.Context, ownerID int64) (int, error)
+	RemoveByURL(ctx context.Context, ownerID int64, url string) (int64, error)
+	Undo(ctx context.Context, ownerID, pageID int64) ([]Page, error)
+	List(ctx context.Context, ownerID int64, username string, f Filter, limit, offset int) ([]Page, error)
+	RemoveFiltered(ctx context.Context, ownerID int64, f Filter) (int, error)
+	Count(ctx context.Context, ownerID int64, f Filter) (int, error)
 	IsExists(ctx context.Context, ownerID int64, url string) (bool, error)
+	SetNote(ctx context.Context, ownerID, pageID int64, note string) error
+	MarkRead(ctx context.Context, p *Page) error
+	Claim(ctx context.Context, ownerID, pageID int64, until time.Time) error
+	Ack(ctx context.Context, p *Page, archive bool) error
+	Release(ctx context.Context, p *Page) error
+	SetPinned(ctx context.Context, ownerID, pageID int64, pinned bool) error
+	Snooze(ctx context.Context, ownerID, pageID int64, until time.Time) error
+	ListDueSnoozed(ctx context.Context, now time.Time, limit int) ([]Page, error)
+	ClearSnooze(ctx context.Context, p *Page) error
+	ListUnenriched(ctx context.Context, limit int) ([]Page, error)
+	SetContent(ctx context.Context, pageID int64, c Content) error
+	ListUnchecked(ctx context.Context, checkedBefore time.Time, limit int) ([]Page, error)
+	SetLinkStatus(ctx context.Context, pageID int64, s LinkStatus) error
+	ListBrokenOwners(ctx context.Context, reportedBefore time.Time, limit int) ([]int64, error)
+	SetBrokenReportAt(ctx context.Context, ownerID int64, at time.Time) error
+	SaveSnapshot(ctx context.Context, s *Snapshot) error
+	Snapshot(ctx context.Context, ownerID, pageID int64) (*Snapshot, error)
+
+	SaveMessageRef(ctx context.Context, chatID, messageID, pageID int64) error
+	PageIDByMessage(ctx context.Context, chatID, messageID int64) (int64, error)
+
+	SetConversation(ctx context.Context, c *Conversation) error
+	GetConversation(ctx context.Context, chatID, userID int64) (*Conversation, error)
+	DeleteConversation(ctx context.Context, chatID, userID int64) error
 
 	ListEnabledUsers(ctx context.Context) ([]User, error)
+	ListUsers(ctx context.Context) ([]User, error)
 	UpdateLastSendAt(ctx context.Context, ownerID, newTime int64, newHour, newMinute int) error
-	UpdateUserInfo(ctx context.Context, ownerID, chatID int64, username string) error
-	SwitchEnable(ctx context.Context, ownerID int64, enabled bool) error
+	UpdateUserInfo(ctx context.Context, ownerID, chatID int64, username, lang string) error
+	UpdateChatInfo(ctx context.Context, chatID int64, title string) error
+	SwitchShared(ctx context.Context, chatID int64, shared bool) error
+	UpdateSettings(ctx context.Context, ownerID int64, s Settings) error
+	SetPickCursor(ctx context.Context, ownerID int64, cursor string) error
 	GetUserInfo(ctx context.Context, ownerID int64) (*User, error)
 }
 
+// OwnerKind tells who owns a list. Telegram group chat ids are negative,
+// so both kinds share the same id space without collisions.
+type OwnerKind string
+
+const (
+	OwnerUser OwnerKind = "user"
+	OwnerChat OwnerKind = "chat"
+)
+
+// User is an owner of a list, either a person or a group chat.
 type User struct {
-	OwnerID    int64
-	ChatID     int64
-	Username   string
-	Timezone   string
-	Enabled    bool
+	OwnerID  int64
+	ChatID   int64
+	Username string // chat title for OwnerChat
+	Kind     OwnerKind
+	Shared   bool // group list mode is on, only for OwnerChat
+	Settings
+	PickCursor string // state of the pick strategy
 	SendHour   int
 	SendMinute int
 	LastSendAt sql.NullInt64 //can be nullable
 }
 
 var (
-	ErrNoSavedPages = errors.New("Storage: no saved pages")
-	ErrNotFound     = errors.New("Storage: page not found")
-	ErrUserNotFound = errors.New("Storage: user not found")
+	ErrNoSavedPages   = errors.New("Storage: no saved pages")
+	ErrNotFound       = errors.New("Storage: page not found")
+	ErrUserNotFound   = errors.New("Storage: user not found")
+	ErrAlreadyExists  = errors.New("Storage: page already exists")
+	ErrNoConversation = errors.New("Storage: no conversation")
+	ErrNoSnapshot     = errors.New("Storage: no snapshot")
+	ErrClaimed        = errors.New("Storage: page is claimed by another delivery")
 )
 
 type Page struct {
@@ -51,7 +102,17 @@ type Page struct {
 	OwnerID   int64 // User.ID
 	ChatID    int64
 	UserName  string
+	Domain    string
+	Tags      []string
+	Note      string
+	Pinned    bool // high priority, delivered before the others
+	Broken    bool // the link was dead on the last check
 	CreatedAt time.Time
+	Content
+
+	SnoozedUntil time.Time // zero if not snoozed
+	ReadAt       time.Time // zero if unread
+	ClaimedUntil time.Time // a delivery is sending the page until then, see Storage.Claim
 }
 
 func (p *Page) Hash() (string, error) {
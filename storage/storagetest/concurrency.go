@@ -0,0 +1,187 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"narasla_bot/storage"
+	"sync"
+	"time"
+)
+
+const (
+	burstWorkers = 16
+	burstPages   = 25
+)
+
+// testConcurrency replays a burst of updates: the consumer handles every event in its own
+// goroutine, so saves, reads, message refs and dialogs of many users hit the storage at once,
+// while the scheduler lists users and moves their send time. Nothing may fail, e.g. with
+// "database is locked", and every write must be kept.
+func testConcurrency(ctx context.Context, st storage.Storage) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+
+	for w := range burstWorkers {
+		ownerID := owner + int64(w)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := burst(ctx, st, ownerID); err != nil {
+				fail(fmt.Errorf("owner %d: %w", ownerID, err))
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range burstPages {
+			users, err := st.ListEnabledUsers(ctx)
+			if err != nil {
+				fail(fmt.Errorf("scheduler: %w", err))
+				return
+			}
+			for _, u := range users {
+				if err := st.UpdateLastSendAt(ctx, u.OwnerID, time.Now().Unix(), 9, 0); err != nil {
+					fail(fmt.Errorf("scheduler: %w", err))
+					return
+				}
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for w := range burstWorkers {
+		// every owner removed one page and restored it with Undo.
+		if err := wantCount(ctx, st, owner+int64(w), storage.Filter{}, burstPages); err != nil {
+			return fmt.Errorf("owner %d: %w", owner+int64(w), err)
+		}
+	}
+
+	return nil
+}
+
+// burst is what one user does: starts the bot, saves pages through a dialog, reads and removes one.
+func burst(ctx context.Context, st storage.Storage, ownerID int64) error {
+	if err := st.UpdateUserInfo(ctx, ownerID, ownerID, "user", "en"); err != nil {
+		return err
+	}
+	if err := st.UpdateSettings(ctx, ownerID, storage.DefaultSettings()); err != nil {
+		return err
+	}
+
+	for i := range burstPages {
+		c := &storage.Conversation{ChatID: ownerID, UserID: ownerID, Flow: "save", Step: "link", ExpiresAt: time.Now().Add(time.Hour)}
+		if err := st.SetConversation(ctx, c); err != nil {
+			return err
+		}
+		if _, err := st.GetConversation(ctx, ownerID, ownerID); err != nil {
+			return err
+		}
+
+		p := &storage.Page{URL: fmt.Sprintf("https://example.com/%d", i), OwnerID: ownerID, ChatID: ownerID}
+		exists, err := st.IsExists(ctx, ownerID, p.URL)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%s exists before Save", p.URL)
+		}
+		if err := st.Save(ctx, p); err != nil {
+			return err
+		}
+		if err := st.SaveMessageRef(ctx, ownerID, int64(i), p.ID); err != nil {
+			return err
+		}
+		if err := st.DeleteConversation(ctx, ownerID, ownerID); err != nil {
+			return err
+		}
+
+		if _, err := st.GetUserInfo(ctx, ownerID); err != nil {
+			return err
+		}
+		if _, err := st.List(ctx, ownerID, "user", storage.Filter{}, 10, 0); err != nil {
+			return err
+		}
+		if _, err := st.PageIDByMessage(ctx, ownerID, int64(i)); err != nil {
+			return err
+		}
+		if err := st.MarkRead(ctx, p); err != nil {
+			return err
+		}
+	}
+
+	pageID, err := st.RemoveByURL(ctx, ownerID, "https://example.com/0")
+	if err != nil {
+		return err
+	}
+	if _, err := st.Undo(ctx, ownerID, pageID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// testConcurrentClaims claims the same page from many goroutines at once,
+// like /rnd racing autopush: exactly one of them may get it.
+func testConcurrentClaims(ctx context.Context, st storage.Storage) error {
+	ids, err := saveN(ctx, st, owner, 1)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		won  int
+		errs []error
+	)
+
+	until := time.Now().Add(time.Hour)
+
+	for range burstWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := st.Claim(ctx, owner, ids[0], until)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case err == nil:
+				won++
+			case !errors.Is(err, storage.ErrClaimed):
+				errs = append(errs, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if won != 1 {
+		return fmt.Errorf("%d concurrent claims of one page won, want 1", won)
+	}
+
+	return nil
+}
@@ -0,0 +1,1040 @@
+// Package storagetest checks that a storage.Storage backend behaves like the others.
+// It's meant to be called from a test of the backend, like testing/fstest:
+//
+//	if err := storagetest.Run(ctx, open); err != nil {
+//		t.Fatal(err)
+//	}
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"narasla_bot/storage"
+	"slices"
+	"time"
+)
+
+// Opener returns a new empty storage and a function that frees it.
+type Opener func() (st storage.Storage, closeFn func() error, err error)
+
+type testCase struct {
+	name string
+	run  func(ctx context.Context, st storage.Storage) error
+}
+
+var cases = []testCase{
+	{"save and count", testSave},
+	{"unique url", testUnique},
+	{"owners are separate", testOwners},
+	{"filters", testFilters},
+	{"order and pagination", testOrder},
+	{"save date", testSaveDate},
+	{"pick nth", testPickNth},
+	{"remove and undo", testUndo},
+	{"undo journal size", testJournalSize},
+	{"remove filtered", testRemoveFiltered},
+	{"page updates", testUpdates},
+	{"snooze", testSnooze},
+	{"claims", testClaims},
+	{"content and link status", testBackground},
+	{"snapshots", testSnapshots},
+	{"message refs", testMessageRefs},
+	{"conversations", testConversations},
+	{"users", testUsers},
+	{"group chats", testChats},
+	{"concurrent writes", testConcurrency},
+	{"concurrent claims", testConcurrentClaims},
+}
+
+// Run runs every case on its own storage from open and returns all failures joined.
+func Run(ctx context.Context, open Opener) error {
+	var errs []error
+
+	for _, c := range cases {
+		if err := runCase(ctx, open, c); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func runCase(ctx context.Context, open Opener, c testCase) (err error) {
+	st, closeFn, err := open()
+	if err != nil {
+		return fmt.Errorf("can't open storage: %w", err)
+	}
+	defer func() {
+		if closeErr := closeFn(); closeErr != nil && err == nil {
+			err = fmt.Errorf("can't close storage: %w", closeErr)
+		}
+	}()
+
+	return c.run(ctx, st)
+}
+
+const (
+	owner = int64(100)
+	other = int64(200)
+)
+
+func testSave(ctx context.Context, st storage.Storage) error {
+	p := &storage.Page{URL: "https://www.Example.com/a", OwnerID: owner, ChatID: owner, Tags: []string{"Go"}, Note: "n"}
+	if err := st.Save(ctx, p); err != nil {
+		return err
+	}
+	if p.ID == 0 {
+		return errors.New("Save didn't set the page id")
+	}
+
+	second := &storage.Page{URL: "https://b.com/", OwnerID: owner, ChatID: owner}
+	if err := st.Save(ctx, second); err != nil {
+		return err
+	}
+	if second.ID <= p.ID {
+		return fmt.Errorf("ids don't grow: %d then %d", p.ID, second.ID)
+	}
+
+	if err := wantCount(ctx, st, owner, storage.Filter{}, 2); err != nil {
+		return err
+	}
+
+	list, err := st.List(ctx, owner, "bob", storage.Filter{}, 10, 0)
+	if err != nil {
+		return err
+	}
+	if len(list) != 2 {
+		return fmt.Errorf("List returned %d pages, want 2", len(list))
+	}
+
+	got := list[0]
+	switch {
+	case got.ID != p.ID || got.URL != p.URL || got.OwnerID != owner:
+		return fmt.Errorf("List returned %+v, want the first saved page", got)
+	case got.Domain != "example.com":
+		return fmt.Errorf("domain is %q, want example.com", got.Domain)
+	case !slices.Equal(got.Tags, []string{"go"}):
+		return fmt.Errorf("tags are %q, want lowercase [go]", got.Tags)
+	case got.Note != "n":
+		return fmt.Errorf("note is %q, want n", got.Note)
+	case got.UserName != "bob":
+		return fmt.Errorf("user name is %q, want the one passed to List", got.UserName)
+	case got.CreatedAt.IsZero():
+		return errors.New("CreatedAt isn't set")
+	}
+
+	exists, err := st.IsExists(ctx, owner, p.URL)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("IsExists is false for a saved page")
+	}
+
+	exists, err = st.IsExists(ctx, owner, "https://nope.com/")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("IsExists is true for a page that wasn't saved")
+	}
+
+	return nil
+}
+
+func testUnique(ctx context.Context, st storage.Storage) error {
+	if err := st.Save(ctx, &storage.Page{URL: "https://a.com/", OwnerID: owner}); err != nil {
+		return err
+	}
+
+	err := st.Save(ctx, &storage.Page{URL: "https://a.com/", OwnerID: owner})
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		return fmt.Errorf("second Save of the url returned %v, want ErrAlreadyExists", err)
+	}
+
+	if err := st.Save(ctx, &storage.Page{URL: "https://a.com/", OwnerID: other}); err != nil {
+		return fmt.Errorf("another owner can't save the url: %w", err)
+	}
+
+	return wantCount(ctx, st, owner, storage.Filter{}, 1)
+}
+
+func testOwners(ctx context.Context, st storage.Storage) error {
+	mine := &storage.Page{URL: "https://a.com/", OwnerID: owner}
+	if err := st.Save(ctx, mine); err != nil {
+		return err
+	}
+
+	if err := wantCount(ctx, st, other, storage.Filter{}, 0); err != nil {
+		return err
+	}
+
+	if _, err := st.PickNth(ctx, other, storage.Filter{}, 0); !errors.Is(err, storage.ErrNoSavedPages) {
+		return fmt.Errorf("PickNth of an empty list returned %v, want ErrNoSavedPages", err)
+	}
+
+	if err := st.SetNote(ctx, other, mine.ID, "x"); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("SetNote of another owner's page returned %v, want ErrNotFound", err)
+	}
+
+	if _, err := st.RemoveByURL(ctx, other, mine.URL); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("RemoveByURL of another owner's page returned %v, want ErrNotFound", err)
+	}
+
+	return wantCount(ctx, st, owner, storage.Filter{}, 1)
+}
+
+func testFilters(ctx context.Context, st storage.Storage) error {
+	pages := []*storage.Page{
+		{URL: "https://www.a.com/1", Tags: []string{"go", "db"}},
+		{URL: "https://a.com/2", Tags: []string{"go"}, Pinned: true},
+		{URL: "https://b.com/3"},
+	}
+	for _, p := range pages {
+		p.OwnerID = owner
+		if err := st.Save(ctx, p); err != nil {
+			return err
+		}
+	}
+
+	if err := st.MarkRead(ctx, pages[0]); err != nil {
+		return err
+	}
+
+	checks := []struct {
+		name string
+		f    storage.Filter
+		want int
+	}{
+		{"domain", storage.Filter{Domain: "A.com"}, 2},
+		{"tags", storage.Filter{Tags: []string{"GO", "db"}}, 1},
+		{"untagged", storage.Filter{Untagged: true}, 1},
+		{"unread", storage.Filter{Unread: true}, 2},
+		{"pinned", storage.Filter{Pinned: true}, 1},
+		{"ids", storage.Filter{IDs: []int64{pages[0].ID, pages[2].ID}}, 2},
+		{"empty ids", storage.Filter{IDs: []int64{}}, 0},
+		{"since", storage.Filter{Since: time.Now().Add(-time.Hour)}, 3},
+		{"before", storage.Filter{Before: time.Now().Add(-time.Hour)}, 0},
+		{"unknown reading time", storage.Filter{MaxMinutes: 5}, 0},
+	}
+
+	for _, c := range checks {
+		if err := wantCount(ctx, st, owner, c.f, c.want); err != nil {
+			return fmt.Errorf("%s filter: %w", c.name, err)
+		}
+	}
+
+	domains, err := st.Domains(ctx, owner, storage.Filter{})
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(domains, []string{"a.com", "b.com"}) {
+		return fmt.Errorf("Domains returned %q, want [a.com b.com]", domains)
+	}
+
+	tags, err := st.Tags(ctx, owner, storage.Filter{})
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(tags, []string{"db", "go"}) {
+		return fmt.Errorf("Tags returned %q, want [db go]", tags)
+	}
+
+	tags, err = st.Tags(ctx, owner, storage.Filter{Pinned: true})
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(tags, []string{"go"}) {
+		return fmt.Errorf("Tags of pinned pages returned %q, want [go]", tags)
+	}
+
+	return nil
+}
+
+func testOrder(ctx context.Context, st storage.Storage) error {
+	ids, err := saveN(ctx, st, owner, 5)
+	if err != nil {
+		return err
+	}
+
+	list, err := st.List(ctx, owner, "", storage.Filter{}, 2, 1)
+	if err != nil {
+		return err
+	}
+	if got := pageIDs(list); !slices.Equal(got, ids[1:3]) {
+		return fmt.Errorf("List(limit 2, offset 1) returned %d, want %d", got, ids[1:3])
+	}
+
+	list, err = st.List(ctx, owner, "", storage.Filter{Order: storage.OrderNewest}, 10, 0)
+	if err != nil {
+		return err
+	}
+	reversed := slices.Clone(ids)
+	slices.Reverse(reversed)
+	if got := pageIDs(list); !slices.Equal(got, reversed) {
+		return fmt.Errorf("newest first returned %d, want %d", got, reversed)
+	}
+
+	list, err = st.List(ctx, owner, "", storage.Filter{}, 10, 10)
+	if err != nil {
+		return err
+	}
+	if len(list) != 0 {
+		return fmt.Errorf("List past the end returned %d pages", len(list))
+	}
+
+	return nil
+}
+
+// testSaveDate checks that a page saved with CreatedAt, like an imported one, keeps it.
+func testSaveDate(ctx context.Context, st storage.Storage) error {
+	if _, err := saveN(ctx, st, owner, 1); err != nil {
+		return err
+	}
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	old := &storage.Page{URL: "https://old.com/", OwnerID: owner, ChatID: owner, CreatedAt: created}
+	if err := st.Save(ctx, old); err != nil {
+		return err
+	}
+
+	list, err := st.List(ctx, owner, "", storage.Filter{Order: storage.OrderOldest}, 10, 0)
+	if err != nil {
+		return err
+	}
+	if len(list) != 2 || list[0].ID != old.ID {
+		return fmt.Errorf("oldest first returned %d, want the imported page %d first", pageIDs(list), old.ID)
+	}
+	if !list[0].CreatedAt.Equal(created) {
+		return fmt.Errorf("CreatedAt is %s, want %s", list[0].CreatedAt, created)
+	}
+
+	return wantCount(ctx, st, owner, storage.Filter{Before: created.AddDate(0, 0, 1)}, 1)
+}
+
+func testPickNth(ctx context.Context, st storage.Storage) error {
+	ids, err := saveN(ctx, st, owner, 3)
+	if err != nil {
+		return err
+	}
+
+	for n, id := range ids {
+		p, err := st.PickNth(ctx, owner, storage.Filter{}, n)
+		if err != nil {
+			return err
+		}
+		if p.ID != id || p.OwnerID != owner {
+			return fmt.Errorf("PickNth(%d) returned page %d of %d, want %d of %d", n, p.ID, p.OwnerID, id, owner)
+		}
+	}
+
+	if _, err := st.PickNth(ctx, owner, storage.Filter{}, len(ids)); !errors.Is(err, storage.ErrNoSavedPages) {
+		return fmt.Errorf("PickNth past the end returned %v, want ErrNoSavedPages", err)
+	}
+
+	return nil
+}
+
+func testUndo(ctx context.Context, st storage.Storage) error {
+	p := &storage.Page{URL: "https://a.com/", OwnerID: owner, ChatID: 5, Tags: []string{"go"}, Note: "n", Pinned: true}
+	if err := st.Save(ctx, p); err != nil {
+		return err
+	}
+
+	if err := st.Remove(ctx, p); err != nil {
+		return err
+	}
+	if err := st.Remove(ctx, p); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("second Remove returned %v, want ErrNotFound", err)
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{}, 0); err != nil {
+		return err
+	}
+
+	if _, err := st.Undo(ctx, other, 0); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("Undo of another owner returned %v, want ErrNotFound", err)
+	}
+
+	pages, err := st.Undo(ctx, owner, 0)
+	if err != nil {
+		return err
+	}
+	if len(pages) != 1 {
+		return fmt.Errorf("Undo restored %d pages, want 1", len(pages))
+	}
+	if restored := pages[0]; restored.ID != p.ID || restored.URL != p.URL || restored.Note != "n" || !restored.Pinned || !slices.Equal(restored.Tags, p.Tags) {
+		return fmt.Errorf("Undo returned %+v, want the removed page", restored)
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{Pinned: true}, 1); err != nil {
+		return err
+	}
+
+	if _, err := st.Undo(ctx, owner, 0); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("Undo of an empty journal returned %v, want ErrNotFound", err)
+	}
+
+	// the url is saved again while the removed copy waits in the journal.
+	id, err := st.RemoveByURL(ctx, owner, p.URL)
+	if err != nil {
+		return err
+	}
+	if id != p.ID {
+		return fmt.Errorf("RemoveByURL returned id %d, want %d", id, p.ID)
+	}
+	if err := st.Save(ctx, &storage.Page{URL: p.URL, OwnerID: owner}); err != nil {
+		return err
+	}
+	if _, err := st.Undo(ctx, owner, p.ID); !errors.Is(err, storage.ErrAlreadyExists) {
+		return fmt.Errorf("Undo of a saved again url returned %v, want ErrAlreadyExists", err)
+	}
+	if _, err := st.Undo(ctx, owner, p.ID); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("the entry stays in the journal after ErrAlreadyExists: %v", err)
+	}
+
+	return wantCount(ctx, st, owner, storage.Filter{}, 1)
+}
+
+// testJournalSize checks that the journal keeps the last 20 removals, and a bulk one counts once.
+func testJournalSize(ctx context.Context, st storage.Storage) error {
+	const size = 20
+
+	ids, err := saveN(ctx, st, owner, size+5)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := st.Remove(ctx, &storage.Page{ID: id, OwnerID: owner}); err != nil {
+			return err
+		}
+	}
+
+	// more pages than the journal size, in one removal.
+	bulk, err := saveN(ctx, st, other, size+5)
+	if err != nil {
+		return err
+	}
+	if _, err := st.RemoveFiltered(ctx, other, storage.Filter{IDs: bulk[:size+2]}); err != nil {
+		return err
+	}
+	if err := st.Remove(ctx, &storage.Page{ID: bulk[size+2], OwnerID: other}); err != nil {
+		return err
+	}
+
+	if _, err := st.Undo(ctx, owner, ids[0]); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("Undo of a page out of the journal returned %v, want ErrNotFound", err)
+	}
+
+	for i := len(ids) - 1; i >= len(ids)-size; i-- {
+		pages, err := st.Undo(ctx, owner, 0)
+		if err != nil {
+			return fmt.Errorf("Undo #%d: %w", len(ids)-i, err)
+		}
+		if len(pages) != 1 || pages[0].ID != ids[i] {
+			return fmt.Errorf("Undo restored %d, want the last removed %d", pageIDs(pages), ids[i])
+		}
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{}, size); err != nil {
+		return err
+	}
+
+	if _, err := st.Undo(ctx, other, 0); err != nil {
+		return err
+	}
+	pages, err := st.Undo(ctx, other, 0)
+	if err != nil {
+		return err
+	}
+	if got := pageIDs(pages); !slices.Equal(got, bulk[:size+2]) {
+		return fmt.Errorf("Undo of the bulk removal restored %d, want %d", got, bulk[:size+2])
+	}
+
+	return wantCount(ctx, st, other, storage.Filter{}, size+5)
+}
+
+func testRemoveFiltered(ctx context.Context, st storage.Storage) error {
+	for _, u := range []string{"https://a.com/1", "https://a.com/2", "https://b.com/3"} {
+		if err := st.Save(ctx, &storage.Page{URL: u, OwnerID: owner}); err != nil {
+			return err
+		}
+	}
+
+	removed, err := st.RemoveFiltered(ctx, owner, storage.Filter{Domain: "a.com"})
+	if err != nil {
+		return err
+	}
+	if removed != 2 {
+		return fmt.Errorf("RemoveFiltered removed %d pages, want 2", removed)
+	}
+
+	removed, err = st.RemoveFiltered(ctx, owner, storage.Filter{Domain: "a.com"})
+	if err != nil {
+		return err
+	}
+	if removed != 0 {
+		return fmt.Errorf("second RemoveFiltered removed %d pages, want 0", removed)
+	}
+
+	// both pages come back with one Undo.
+	pages, err := st.Undo(ctx, owner, 0)
+	if err != nil {
+		return err
+	}
+	if len(pages) != 2 {
+		return fmt.Errorf("Undo restored %d pages of the removal, want 2", len(pages))
+	}
+
+	return wantCount(ctx, st, owner, storage.Filter{}, 3)
+}
+
+func testUpdates(ctx context.Context, st storage.Storage) error {
+	p := &storage.Page{URL: "https://a.com/", OwnerID: owner}
+	if err := st.Save(ctx, p); err != nil {
+		return err
+	}
+
+	if err := st.SetNote(ctx, owner, p.ID, "later"); err != nil {
+		return err
+	}
+	if err := st.SetPinned(ctx, owner, p.ID, true); err != nil {
+		return err
+	}
+
+	got, err := st.PickNth(ctx, owner, storage.Filter{}, 0)
+	if err != nil {
+		return err
+	}
+	if got.Note != "later" || !got.Pinned {
+		return fmt.Errorf("page after SetNote and SetPinned is %+v", got)
+	}
+
+	missing := p.ID + 1000
+	if err := st.SetNote(ctx, owner, missing, "x"); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("SetNote of a missing page returned %v, want ErrNotFound", err)
+	}
+	if err := st.SetPinned(ctx, owner, missing, true); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("SetPinned of a missing page returned %v, want ErrNotFound", err)
+	}
+	if err := st.Snooze(ctx, owner, missing, time.Now()); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("Snooze of a missing page returned %v, want ErrNotFound", err)
+	}
+
+	if err := st.MarkRead(ctx, p); err != nil {
+		return err
+	}
+
+	return wantCount(ctx, st, owner, storage.Filter{Unread: true}, 0)
+}
+
+func testSnooze(ctx context.Context, st storage.Storage) error {
+	ids, err := saveN(ctx, st, owner, 3)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if err := st.MarkRead(ctx, &storage.Page{ID: ids[0], OwnerID: owner}); err != nil {
+		return err
+	}
+	if err := st.Snooze(ctx, owner, ids[0], now.Add(-time.Minute)); err != nil {
+		return err
+	}
+	if err := st.Snooze(ctx, owner, ids[1], now.Add(-time.Hour)); err != nil {
+		return err
+	}
+	if err := st.Snooze(ctx, owner, ids[2], now.Add(time.Hour)); err != nil {
+		return err
+	}
+
+	if err := wantCount(ctx, st, owner, storage.Filter{Unread: true}, 3); err != nil {
+		return fmt.Errorf("snoozed page isn't unread: %w", err)
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{Awake: true}, 2); err != nil {
+		return err
+	}
+
+	due, err := st.ListDueSnoozed(ctx, now, 10)
+	if err != nil {
+		return err
+	}
+	if got := pageIDs(due); !slices.Equal(got, []int64{ids[1], ids[0]}) {
+		return fmt.Errorf("ListDueSnoozed returned %d, want %d", got, []int64{ids[1], ids[0]})
+	}
+
+	if err := st.ClearSnooze(ctx, &due[0]); err != nil {
+		return err
+	}
+
+	due, err = st.ListDueSnoozed(ctx, now, 10)
+	if err != nil {
+		return err
+	}
+	if len(due) != 1 {
+		return fmt.Errorf("ListDueSnoozed after ClearSnooze returned %d pages, want 1", len(due))
+	}
+
+	return nil
+}
+
+func testClaims(ctx context.Context, st storage.Storage) error {
+	ids, err := saveN(ctx, st, owner, 3)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	first := &storage.Page{ID: ids[0], OwnerID: owner}
+
+	if err := st.Claim(ctx, owner, ids[0], now.Add(time.Hour)); err != nil {
+		return err
+	}
+	if err := st.Claim(ctx, owner, ids[0], now.Add(time.Hour)); !errors.Is(err, storage.ErrClaimed) {
+		return fmt.Errorf("second Claim returned %v, want ErrClaimed", err)
+	}
+	if err := st.Claim(ctx, other, ids[1], now.Add(time.Hour)); !errors.Is(err, storage.ErrClaimed) {
+		return fmt.Errorf("Claim of another owner's page returned %v, want ErrClaimed", err)
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{Free: true}, 2); err != nil {
+		return err
+	}
+
+	if err := st.Release(ctx, first); err != nil {
+		return err
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{Free: true}, 3); err != nil {
+		return fmt.Errorf("released page isn't free: %w", err)
+	}
+
+	// a lease that is over doesn't hold the page.
+	if err := st.Claim(ctx, owner, ids[1], now.Add(-time.Minute)); err != nil {
+		return err
+	}
+	if err := st.Claim(ctx, owner, ids[1], now.Add(time.Hour)); err != nil {
+		return fmt.Errorf("Claim after the lease is over: %w", err)
+	}
+
+	if err := st.Claim(ctx, owner, ids[0], now.Add(time.Hour)); err != nil {
+		return err
+	}
+	if err := st.Ack(ctx, first, true); err != nil {
+		return err
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{Unread: true}, 2); err != nil {
+		return fmt.Errorf("Ack with archive didn't mark the page read: %w", err)
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{Free: true}, 2); err != nil {
+		return fmt.Errorf("Ack with archive didn't free the page: %w", err)
+	}
+
+	second := &storage.Page{ID: ids[1], OwnerID: owner}
+	if err := st.Ack(ctx, second, false); err != nil {
+		return err
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{}, 2); err != nil {
+		return fmt.Errorf("Ack didn't remove the page: %w", err)
+	}
+	if err := st.Ack(ctx, second, false); err != nil {
+		return fmt.Errorf("Ack of a removed page returned %v, want nil", err)
+	}
+
+	if _, err := st.Undo(ctx, owner, ids[1]); err != nil {
+		return err
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{Free: true}, 3); err != nil {
+		return fmt.Errorf("restored page is still claimed: %w", err)
+	}
+
+	return nil
+}
+
+func testBackground(ctx context.Context, st storage.Storage) error {
+	ids, err := saveN(ctx, st, owner, 2)
+	if err != nil {
+		return err
+	}
+	otherIDs, err := saveN(ctx, st, other, 1)
+	if err != nil {
+		return err
+	}
+
+	unenriched, err := st.ListUnenriched(ctx, 10)
+	if err != nil {
+		return err
+	}
+	if got, want := pageIDs(unenriched), append(slices.Clone(ids), otherIDs...); !slices.Equal(got, want) {
+		return fmt.Errorf("ListUnenriched returned %d, want %d", got, want)
+	}
+
+	if err := st.SetContent(ctx, ids[0], storage.Content{Kind: storage.KindArticle, ReadMinutes: 7}); err != nil {
+		return err
+	}
+
+	unenriched, err = st.ListUnenriched(ctx, 1)
+	if err != nil {
+		return err
+	}
+	if got := pageIDs(unenriched); !slices.Equal(got, ids[1:]) {
+		return fmt.Errorf("ListUnenriched after SetContent returned %d, want %d", got, ids[1:])
+	}
+	if err := wantCount(ctx, st, owner, storage.Filter{Kind: storage.KindArticle, MinMinutes: 5}, 1); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := st.SetLinkStatus(ctx, ids[1], storage.LinkStatus{Code: 404, Broken: true, CheckedAt: now}); err != nil {
+		return err
+	}
+
+	unchecked, err := st.ListUnchecked(ctx, now.Add(-time.Hour), 10)
+	if err != nil {
+		return err
+	}
+	if got, want := pageIDs(unchecked), []int64{ids[0], otherIDs[0]}; !slices.Equal(got, want) {
+		return fmt.Errorf("ListUnchecked returned %d, want %d", got, want)
+	}
+
+	if err := wantCount(ctx, st, owner, storage.Filter{Broken: true}, 1); err != nil {
+		return err
+	}
+
+	if err := st.UpdateUserInfo(ctx, owner, owner, "bob", "en"); err != nil {
+		return err
+	}
+
+	owners, err := st.ListBrokenOwners(ctx, now, 10)
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(owners, []int64{owner}) {
+		return fmt.Errorf("ListBrokenOwners returned %d, want [%d]", owners, owner)
+	}
+
+	if err := st.SetBrokenReportAt(ctx, owner, now); err != nil {
+		return err
+	}
+
+	owners, err = st.ListBrokenOwners(ctx, now.Add(-time.Hour), 10)
+	if err != nil {
+		return err
+	}
+	if len(owners) != 0 {
+		return fmt.Errorf("ListBrokenOwners after the report returned %d", owners)
+	}
+
+	return nil
+}
+
+func testSnapshots(ctx context.Context, st storage.Storage) error {
+	p := &storage.Page{URL: "https://a.com/", OwnerID: owner}
+	if err := st.Save(ctx, p); err != nil {
+		return err
+	}
+
+	if _, err := st.Snapshot(ctx, owner, p.ID); !errors.Is(err, storage.ErrNoSnapshot) {
+		return fmt.Errorf("Snapshot before SaveSnapshot returned %v, want ErrNoSnapshot", err)
+	}
+
+	if err := st.SaveSnapshot(ctx, &storage.Snapshot{PageID: p.ID, Title: "T", Text: "one\n\ntwo"}); err != nil {
+		return err
+	}
+
+	snap, err := st.Snapshot(ctx, owner, p.ID)
+	if err != nil {
+		return err
+	}
+	if snap.PageID != p.ID || snap.Title != "T" || snap.Text != "one\n\ntwo" || snap.CreatedAt.IsZero() {
+		return fmt.Errorf("Snapshot returned %+v", snap)
+	}
+
+	if _, err := st.Snapshot(ctx, other, p.ID); !errors.Is(err, storage.ErrNoSnapshot) {
+		return fmt.Errorf("Snapshot of another owner's page returned %v, want ErrNoSnapshot", err)
+	}
+
+	if err := st.Remove(ctx, p); err != nil {
+		return err
+	}
+	if _, err := st.Snapshot(ctx, owner, p.ID); !errors.Is(err, storage.ErrNoSnapshot) {
+		return fmt.Errorf("Snapshot of a removed page returned %v, want ErrNoSnapshot", err)
+	}
+
+	if _, err := st.Undo(ctx, owner, 0); err != nil {
+		return err
+	}
+	if _, err := st.Snapshot(ctx, owner, p.ID); err != nil {
+		return fmt.Errorf("snapshot is lost after Undo: %w", err)
+	}
+
+	return nil
+}
+
+func testMessageRefs(ctx context.Context, st storage.Storage) error {
+	p := &storage.Page{URL: "https://a.com/", OwnerID: owner}
+	if err := st.Save(ctx, p); err != nil {
+		return err
+	}
+
+	if err := st.SaveMessageRef(ctx, 1, 10, p.ID); err != nil {
+		return err
+	}
+
+	id, err := st.PageIDByMessage(ctx, 1, 10)
+	if err != nil {
+		return err
+	}
+	if id != p.ID {
+		return fmt.Errorf("PageIDByMessage returned %d, want %d", id, p.ID)
+	}
+
+	if _, err := st.PageIDByMessage(ctx, 2, 10); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("PageIDByMessage of an unknown message returned %v, want ErrNotFound", err)
+	}
+
+	if err := st.Remove(ctx, p); err != nil {
+		return err
+	}
+	if _, err := st.PageIDByMessage(ctx, 1, 10); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("PageIDByMessage of a removed page returned %v, want ErrNotFound", err)
+	}
+
+	return nil
+}
+
+func testConversations(ctx context.Context, st storage.Storage) error {
+	if _, err := st.GetConversation(ctx, 1, 2); !errors.Is(err, storage.ErrNoConversation) {
+		return fmt.Errorf("GetConversation before SetConversation returned %v, want ErrNoConversation", err)
+	}
+
+	c := &storage.Conversation{ChatID: 1, UserID: 2, Flow: "save", Step: "link", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := st.SetConversation(ctx, c); err != nil {
+		return err
+	}
+
+	c.Step = "tags"
+	c.Data = "https://a.com/"
+	if err := st.SetConversation(ctx, c); err != nil {
+		return err
+	}
+
+	got, err := st.GetConversation(ctx, 1, 2)
+	if err != nil {
+		return err
+	}
+	if got.Flow != "save" || got.Step != "tags" || got.Data != c.Data {
+		return fmt.Errorf("GetConversation returned %+v", got)
+	}
+
+	if err := st.DeleteConversation(ctx, 1, 2); err != nil {
+		return err
+	}
+	if _, err := st.GetConversation(ctx, 1, 2); !errors.Is(err, storage.ErrNoConversation) {
+		return fmt.Errorf("GetConversation after DeleteConversation returned %v, want ErrNoConversation", err)
+	}
+
+	c.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := st.SetConversation(ctx, c); err != nil {
+		return err
+	}
+	if _, err := st.GetConversation(ctx, 1, 2); !errors.Is(err, storage.ErrNoConversation) {
+		return fmt.Errorf("GetConversation of an expired conversation returned %v, want ErrNoConversation", err)
+	}
+
+	return nil
+}
+
+func testUsers(ctx context.Context, st storage.Storage) error {
+	if _, err := st.GetUserInfo(ctx, owner); !errors.Is(err, storage.ErrUserNotFound) {
+		return fmt.Errorf("GetUserInfo of an unknown user returned %v, want ErrUserNotFound", err)
+	}
+	if err := st.UpdateSettings(ctx, owner, storage.DefaultSettings()); !errors.Is(err, storage.ErrUserNotFound) {
+		return fmt.Errorf("UpdateSettings of an unknown user returned %v, want ErrUserNotFound", err)
+	}
+
+	if err := st.UpdateUserInfo(ctx, owner, 5, "bob", "ru"); err != nil {
+		return err
+	}
+	if err := st.UpdateUserInfo(ctx, owner, 6, "robert", "en"); err != nil {
+		return err
+	}
+
+	u, err := st.GetUserInfo(ctx, owner)
+	if err != nil {
+		return err
+	}
+	switch {
+	case u.ChatID != 6 || u.Username != "robert":
+		return fmt.Errorf("UpdateUserInfo didn't update chat and name: %+v", u)
+	case u.Lang != "ru":
+		return fmt.Errorf("lang is %q, want the first known ru", u.Lang)
+	case u.Kind != storage.OwnerUser:
+		return fmt.Errorf("kind is %q, want user", u.Kind)
+	case u.Settings != withLang(storage.DefaultSettings(), "ru"):
+		return fmt.Errorf("new user settings are %+v, want the defaults", u.Settings)
+	}
+
+	set := storage.Settings{Autopush: false, Timezone: "Europe/Moscow", WindowStart: 10, WindowEnd: 20, Archive: true, Lang: "en", Strategy: "oldest"}
+	if err := st.UpdateSettings(ctx, owner, set); err != nil {
+		return err
+	}
+	if err := st.SetPickCursor(ctx, owner, "42"); err != nil {
+		return err
+	}
+	if err := st.UpdateLastSendAt(ctx, owner, 1000, 13, 30); err != nil {
+		return err
+	}
+
+	u, err = st.GetUserInfo(ctx, owner)
+	if err != nil {
+		return err
+	}
+	switch {
+	case u.Settings != set:
+		return fmt.Errorf("settings are %+v, want %+v", u.Settings, set)
+	case u.PickCursor != "42":
+		return fmt.Errorf("pick cursor is %q, want 42", u.PickCursor)
+	case u.LastSendAt.Int64 != 1000 || u.SendHour != 13 || u.SendMinute != 30:
+		return fmt.Errorf("last send is %v at %d:%d", u.LastSendAt, u.SendHour, u.SendMinute)
+	}
+
+	users, err := st.ListEnabledUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(users) != 0 {
+		return fmt.Errorf("ListEnabledUsers returned %d users with autopush off", len(users))
+	}
+
+	set.Autopush = true
+	if err := st.UpdateSettings(ctx, owner, set); err != nil {
+		return err
+	}
+
+	users, err = st.ListEnabledUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(users) != 1 || users[0].OwnerID != owner || !users[0].Autopush || users[0].Shared {
+		return fmt.Errorf("ListEnabledUsers returned %+v", users)
+	}
+
+	return nil
+}
+
+func testChats(ctx context.Context, st storage.Storage) error {
+	const chat = int64(-300)
+
+	if err := st.SwitchShared(ctx, chat, true); !errors.Is(err, storage.ErrUserNotFound) {
+		return fmt.Errorf("SwitchShared of an unknown chat returned %v, want ErrUserNotFound", err)
+	}
+
+	if err := st.UpdateChatInfo(ctx, chat, "team"); err != nil {
+		return err
+	}
+	if err := st.UpdateChatInfo(ctx, chat, "new team"); err != nil {
+		return err
+	}
+
+	u, err := st.GetUserInfo(ctx, chat)
+	if err != nil {
+		return err
+	}
+	if u.Kind != storage.OwnerChat || u.ChatID != chat || u.Username != "new team" || u.Autopush || u.Shared {
+		return fmt.Errorf("new chat is %+v", u)
+	}
+
+	if err := st.SwitchShared(ctx, chat, true); err != nil {
+		return err
+	}
+
+	set := u.Settings
+	set.Autopush = true
+	if err := st.UpdateSettings(ctx, chat, set); err != nil {
+		return err
+	}
+
+	users, err := st.ListEnabledUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(users) != 1 || users[0].OwnerID != chat || !users[0].Shared {
+		return fmt.Errorf("ListEnabledUsers returned %+v, want the shared chat", users)
+	}
+
+	if err := st.SwitchShared(ctx, chat, false); err != nil {
+		return err
+	}
+
+	users, err = st.ListEnabledUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(users) != 0 {
+		return fmt.Errorf("ListEnabledUsers returned %+v for a chat with personal lists", users)
+	}
+
+	if err := st.UpdateUserInfo(ctx, owner, owner, "bob", ""); err != nil {
+		return err
+	}
+	if err := st.SwitchShared(ctx, owner, true); !errors.Is(err, storage.ErrUserNotFound) {
+		return fmt.Errorf("SwitchShared of a user returned %v, want ErrUserNotFound", err)
+	}
+
+	all, err := st.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(all) != 2 || all[0].OwnerID != chat || all[1].OwnerID != owner || all[0].Shared {
+		return fmt.Errorf("ListUsers returned %+v, want the chat with personal lists and the user", all)
+	}
+
+	return nil
+}
+
+func wantCount(ctx context.Context, st storage.Storage, ownerID int64, f storage.Filter, want int) error {
+	n, err := st.Count(ctx, ownerID, f)
+	if err != nil {
+		return err
+	}
+	if n != want {
+		return fmt.Errorf("Count returned %d, want %d", n, want)
+	}
+
+	list, err := st.List(ctx, ownerID, "", f, want+10, 0)
+	if err != nil {
+		return err
+	}
+	if len(list) != want {
+		return fmt.Errorf("List returned %d pages, want %d", len(list), want)
+	}
+
+	return nil
+}
+
+// saveN saves n pages of the owner and returns their ids.
+func saveN(ctx context.Context, st storage.Storage, ownerID int64, n int) ([]int64, error) {
+	ids := make([]int64, 0, n)
+	for i := range n {
+		p := &storage.Page{URL: fmt.Sprintf("https://example.com/%d", i), OwnerID: ownerID, ChatID: ownerID}
+		if err := st.Save(ctx, p); err != nil {
+			return nil, err
+		}
+		ids = append(ids, p.ID)
+	}
+
+	return ids, nil
+}
+
+func pageIDs(pages []storage.Page) []int64 {
+	ids := make([]int64, 0, len(pages))
+	for _, p := range pages {
+		ids = append(ids, p.ID)
+	}
+
+	return ids
+}
+
+func withLang(s storage.Settings, lang string) storage.Settings {
+	s.Lang = lang
+
+	return s
+}